@@ -0,0 +1,250 @@
+// Package address 日本の住所の分解と構成要素毎の類似度
+package address
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"lcs"
+)
+
+// Address 住所の構成要素. 数字は半角に統一する
+type Address struct {
+	PostalCode   string // 郵便番号 "106-0041"
+	Prefecture   string // 都道府県
+	Municipality string // 市区町村. 郡、政令指定都市の区を含む
+	Town         string // 町域
+	Chome        string // 丁目
+	Banchi       string // 番地
+	Go           string // 号
+	Room         string // 号の後の4つ目の番号. 部屋番号 "1-2-3-405" の "405"
+	Building     string // 建物名
+	Floor        string // 階. 地下は "B1"
+}
+
+//nolint:gochecknoglobals
+var (
+	prefectures = []string{
+		"北海道", "青森県", "岩手県", "宮城県", "秋田県", "山形県", "福島県",
+		"茨城県", "栃木県", "群馬県", "埼玉県", "千葉県", "東京都", "神奈川県",
+		"新潟県", "富山県", "石川県", "福井県", "山梨県", "長野県", "岐阜県",
+		"静岡県", "愛知県", "三重県", "滋賀県", "京都府", "大阪府", "兵庫県",
+		"奈良県", "和歌山県", "鳥取県", "島根県", "岡山県", "広島県", "山口県",
+		"徳島県", "香川県", "愛媛県", "高知県", "福岡県", "佐賀県", "長崎県",
+		"熊本県", "大分県", "宮崎県", "鹿児島県", "沖縄県",
+	}
+
+	// 区を持つ政令指定都市
+	designatedCities = []string{
+		"札幌市", "仙台市", "さいたま市", "千葉市", "横浜市", "川崎市", "相模原市",
+		"新潟市", "静岡市", "浜松市", "名古屋市", "京都市", "大阪市", "堺市",
+		"神戸市", "岡山市", "広島市", "北九州市", "福岡市", "熊本市",
+	}
+
+	// 接尾辞だけでは区切れない市. 名前が市で終わる市と、名前に郡を含む市
+	irregularMunicipalities = []string{"四日市市", "廿日市市", "野々市市", "大和郡山市"}
+
+	postalCodePattern = regexp.MustCompile(`^〒?\s*(\d{3})-?(\d{4})\s*`)
+	kanjiBlockPattern = regexp.MustCompile(`^[〇一二三四五六七八九十百千]+(丁目|番地|番|号)`)
+	blockPattern      = regexp.MustCompile(`^(\d+)(丁目|番地|番|号|-)?`)
+	floorPattern      = regexp.MustCompile(`\s*(B|地下)?(\d+)(?:F|階)$`)
+
+	// 丁目、番地、号の順序. 単位の無い数字は号として扱う
+	blockRanks = map[string]int{"丁目": 0, "番地": 1, "番": 1, "号": 2, "": 2}
+
+	// 全角英数字と長音符、ハイフンの異体字を統一する
+	normalizer = &lcs.Normalizer{Width: true, Dash: true}
+	numerals   = &lcs.Normalizer{Numeral: lcs.ToDigit}
+)
+
+// Parse 住所の文字列を構成要素に分ける
+// 丁目、番地、号は「1丁目3番1号」「1丁目3-1」「1-3-1」のいずれの表記も受け付け、「1-3-1-405」の4つ目の番号は部屋番号とする. 分けられなかった残りは建物名とする
+func Parse(s string) (address Address) {
+	s = normalizer.Normalize(strings.TrimSpace(s))
+
+	if m := postalCodePattern.FindStringSubmatch(s); m != nil {
+		address.PostalCode = m[1] + "-" + m[2]
+		s = s[len(m[0]):]
+	}
+
+	// 構成要素の間の空白は区切りとして読み飛ばす
+	address.Prefecture, s = cutPrefix(s, prefectures)
+	address.Municipality, s = cutMunicipality(strings.TrimLeft(s, " "))
+	s = strings.TrimLeft(s, " ")
+
+	end := townEnd(s)
+	address.Town, s = strings.TrimSpace(s[:end]), s[end:]
+	s = address.cutBlock(s)
+
+	if m := floorPattern.FindStringSubmatch(s); m != nil {
+		if m[1] != "" {
+			address.Floor = "B"
+		}
+
+		address.Floor += m[2]
+		s = s[:len(s)-len(m[0])]
+	}
+
+	address.Building = strings.TrimSpace(strings.TrimLeft(s, "- "))
+
+	return
+}
+
+// 候補のいずれかで始まれば、その候補と残りを返却する
+func cutPrefix(s string, candidates []string) (prefix, rest string) {
+	for _, candidate := range candidates {
+		if strings.HasPrefix(s, candidate) {
+			return candidate, s[len(candidate):]
+		}
+	}
+
+	return "", s
+}
+
+// 市区町村を接尾辞で区切る
+// 市、区を町、村より優先し、町田市、村上市等の名前の中の町、村では区切らない. 郡があれば郡と続く町村を1つの市区町村とする
+func cutMunicipality(s string) (municipality, rest string) {
+	if city, rest := cutPrefix(s, designatedCities); city != "" {
+		if ward, after := cutName(rest, "市区町村"); strings.HasSuffix(ward, "区") {
+			return city + ward, after
+		}
+
+		return city, rest
+	}
+
+	if municipality, rest = cutPrefix(s, irregularMunicipalities); municipality != "" {
+		return
+	}
+
+	if county, after := cutName(s, "郡"); county != "" {
+		if town, after := cutName(after, "町村"); town != "" {
+			return county + town, after
+		}
+	}
+
+	if municipality, rest = cutName(s, "市区"); municipality != "" {
+		return
+	}
+
+	return cutName(s, "町村")
+}
+
+// 最初の数字か空白までの範囲で、1文字以上の名前に続く最初の接尾辞までを区切る
+// 名前の先頭の文字は接尾辞とみなさないので、市原市、町田市は最初の文字で区切らない
+func cutName(s, suffixes string) (name, rest string) {
+	for i, r := range s {
+		if unicode.IsSpace(r) || ('0' <= r && r <= '9') {
+			break
+		}
+
+		if i > 0 && strings.ContainsRune(suffixes, r) {
+			end := i + utf8.RuneLen(r)
+			return s[:end], s[end:]
+		}
+	}
+
+	return "", s
+}
+
+// 町域の終わり. 最初の数字か、丁目等が続く漢数字の位置とする
+// 番地、番、号が続く漢数字は後ろに下位の番号が続く場合だけ区切りとし、町域名 (麻布十番、一番町等) の一部は残す
+func townEnd(s string) int {
+	for i, r := range s {
+		if '0' <= r && r <= '9' {
+			return i
+		}
+
+		m := kanjiBlockPattern.FindStringSubmatch(s[i:])
+		if m == nil {
+			continue
+		}
+
+		if m[1] == "丁目" {
+			return i
+		}
+
+		if unit, ok := leadingUnit(s[i+len(m[0]):]); ok && blockRanks[m[1]] < blockRanks[unit] {
+			return i
+		}
+	}
+
+	return len(s)
+}
+
+// 先頭の番号の単位
+func leadingUnit(s string) (string, bool) {
+	if m := blockPattern.FindStringSubmatch(s); m != nil {
+		_, ok := blockRanks[m[2]]
+		return m[2], ok
+	}
+
+	if m := kanjiBlockPattern.FindStringSubmatch(s); m != nil {
+		return m[1], true
+	}
+
+	return "", false
+}
+
+// 丁目、番地、号、部屋番号を読み取り、残りを返却する
+// 単位の無い数字は直前の単位の次に割り当て、単位が無い3つ以上の数字は丁目から順に割り当てる. 5つ目以降の数字は残りに含める
+func (address *Address) cutBlock(s string) string {
+	type number struct {
+		value, unit string
+	}
+
+	var (
+		numbers  []number
+		labelled bool
+	)
+
+	slots := []*string{&address.Chome, &address.Banchi, &address.Go, &address.Room}
+
+	for len(numbers) < len(slots) {
+		// 丁目等の前の漢数字を数字にする. 町域名の漢数字 (三田等) は町域に含めているので変えない
+		if m := kanjiBlockPattern.FindString(s); m != "" {
+			s = numerals.Normalize(m) + s[len(m):]
+		}
+
+		m := blockPattern.FindStringSubmatch(s)
+		if m == nil {
+			break
+		}
+
+		numbers = append(numbers, number{value: m[1], unit: m[2]})
+		labelled = labelled || (m[2] != "" && m[2] != "-")
+		s = s[len(m[0]):]
+
+		// 単位が無ければ数字の並びの終わり
+		if m[2] == "" {
+			break
+		}
+	}
+
+	next := 1
+	if !labelled && len(numbers) >= 3 {
+		next = 0
+	}
+
+	for _, n := range numbers {
+		slot := next
+
+		switch n.unit {
+		case "丁目":
+			slot = 0
+		case "番地", "番":
+			slot = 1
+		case "号":
+			slot = 2
+		}
+
+		if slot < len(slots) {
+			*slots[slot] = n.value
+		}
+
+		next = slot + 1
+	}
+
+	return s
+}
@@ -0,0 +1,135 @@
+package address_test
+
+import (
+	"lcs/address"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("building and floor", func(t *testing.T) {
+		a := address.Parse("〒106-0041東京都港区麻布台1丁目3-1麻布台ヒルズ森JPタワー 23F")
+		assert.Equal(t, address.Address{
+			PostalCode:   "106-0041",
+			Prefecture:   "東京都",
+			Municipality: "港区",
+			Town:         "麻布台",
+			Chome:        "1",
+			Banchi:       "3",
+			Go:           "1",
+			Building:     "麻布台ヒルズ森JPタワー",
+			Floor:        "23",
+		}, a)
+	})
+
+	t.Run("block notations", func(t *testing.T) {
+		expected := address.Address{Prefecture: "東京都", Municipality: "港区", Town: "麻布台", Chome: "1", Banchi: "3", Go: "1"}
+
+		for _, s := range []string{
+			"東京都港区麻布台1丁目3番1号",
+			"東京都港区麻布台一丁目三番一号",
+			"東京都港区麻布台1-3-1",
+			"東京都港区麻布台１－３－１",
+			"東京都 港区 麻布台1丁目3ー1",
+		} {
+			assert.Equal(t, expected, address.Parse(s), s)
+		}
+
+		a := address.Parse("京都府京都市下京区東塩小路町721番地")
+		assert.Equal(t, "京都市下京区", a.Municipality)
+		assert.Equal(t, "東塩小路町", a.Town)
+		assert.Equal(t, "721", a.Banchi)
+		assert.Empty(t, a.Chome)
+
+		a = address.Parse("神奈川県横浜市中区山下町10-5")
+		assert.Equal(t, "横浜市中区", a.Municipality)
+		assert.Equal(t, "山下町", a.Town)
+		assert.Equal(t, "10", a.Banchi)
+		assert.Equal(t, "5", a.Go)
+	})
+
+	t.Run("room", func(t *testing.T) {
+		a := address.Parse("東京都港区麻布台1-2-3-405")
+		assert.Equal(t, []string{"1", "2", "3", "405"}, []string{a.Chome, a.Banchi, a.Go, a.Room})
+		assert.Empty(t, a.Building)
+
+		a = address.Parse("東京都港区麻布台1丁目2番3号405")
+		assert.Equal(t, []string{"1", "2", "3", "405"}, []string{a.Chome, a.Banchi, a.Go, a.Room})
+
+		a = address.Parse("東京都港区麻布台1丁目2-3-405 麻布台ヒルズ")
+		assert.Equal(t, []string{"1", "2", "3", "405"}, []string{a.Chome, a.Banchi, a.Go, a.Room})
+		assert.Equal(t, "麻布台ヒルズ", a.Building)
+
+		// 5つ目以降の数字は建物名に残す
+		a = address.Parse("東京都港区麻布台1-2-3-4-5")
+		assert.Equal(t, "4", a.Room)
+		assert.Equal(t, "5", a.Building)
+	})
+
+	t.Run("municipality", func(t *testing.T) {
+		assert.Equal(t, "西多摩郡瑞穂町", address.Parse("東京都西多摩郡瑞穂町箱根ケ崎2335").Municipality)
+		assert.Equal(t, "四日市市", address.Parse("三重県四日市市諏訪町1-5").Municipality)
+		assert.Equal(t, "郡山市", address.Parse("福島県郡山市朝日1-23-7").Municipality)
+		assert.Equal(t, "大和郡山市", address.Parse("奈良県大和郡山市北郡山町248-4").Municipality)
+		assert.Equal(t, "余市郡余市町", address.Parse("北海道余市郡余市町朝日町4").Municipality)
+
+		// 名前の中の市区町村の文字では区切らない
+		for s, expected := range map[string][2]string{
+			"新潟県村上市三之町1-1":      {"村上市", "三之町"},
+			"千葉県市原市国分寺台中央1-1-1": {"市原市", "国分寺台中央"},
+			"東京都町田市森野2-2-22":    {"町田市", "森野"},
+			"東京都町田市原町田4-1-17":   {"町田市", "原町田"},
+			"長野県大町市大町3887":      {"大町市", "大町"},
+			"千葉県市川市市川1-1":       {"市川市", "市川"},
+			"東京都新宿区市谷本村町5-1":    {"新宿区", "市谷本村町"},
+			"三重県津市西丸之内23-1":     {"津市", "西丸之内"},
+		} {
+			a := address.Parse(s)
+			assert.Equal(t, expected[0], a.Municipality, s)
+			assert.Equal(t, expected[1], a.Town, s)
+		}
+
+		// 町域名の漢数字は残す
+		a := address.Parse("東京都港区三田三丁目5-27 B1F")
+		assert.Equal(t, "三田", a.Town)
+		assert.Equal(t, "3", a.Chome)
+		assert.Equal(t, "B1", a.Floor)
+		assert.Empty(t, a.Building)
+	})
+
+	t.Run("numerals in town", func(t *testing.T) {
+		a := address.Parse("東京都港区麻布十番")
+		assert.Equal(t, "麻布十番", a.Town)
+		assert.Empty(t, a.Banchi)
+
+		a = address.Parse("東京都港区麻布十番二丁目3-1")
+		assert.Equal(t, "麻布十番", a.Town)
+		assert.Equal(t, "2", a.Chome)
+		assert.Equal(t, "3", a.Banchi)
+		assert.Equal(t, "1", a.Go)
+
+		a = address.Parse("東京都千代田区一番町10-2")
+		assert.Equal(t, "千代田区", a.Municipality)
+		assert.Equal(t, "一番町", a.Town)
+		assert.Equal(t, "10", a.Banchi)
+		assert.Equal(t, "2", a.Go)
+
+		a = address.Parse("東京都港区芝五番三号")
+		assert.Equal(t, "芝", a.Town)
+		assert.Equal(t, "5", a.Banchi)
+		assert.Equal(t, "3", a.Go)
+	})
+
+	t.Run("without prefecture", func(t *testing.T) {
+		a := address.Parse("港区麻布台1-3-1 麻布台ヒルズ森JPタワー23階")
+		assert.Empty(t, a.Prefecture)
+		assert.Equal(t, "港区", a.Municipality)
+		assert.Equal(t, "麻布台ヒルズ森JPタワー", a.Building)
+		assert.Equal(t, "23", a.Floor)
+
+		a = address.Parse("港区麻布台1-3-1 麻布台ヒルズ 地下2階")
+		assert.Equal(t, "麻布台ヒルズ", a.Building)
+		assert.Equal(t, "B2", a.Floor)
+	})
+}
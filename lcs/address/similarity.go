@@ -0,0 +1,114 @@
+package address
+
+import "lcs"
+
+// Weights 構成要素毎の重み. 0の要素は比較しない
+type Weights struct {
+	PostalCode   float32
+	Prefecture   float32
+	Municipality float32
+	Town         float32
+	Block        float32 // 丁目、番地、号、部屋番号
+	Building     float32
+	Floor        float32
+}
+
+//nolint:gochecknoglobals
+var names = lcs.DefaultNormalizer()
+
+// DefaultWeights 所在地を表す要素を重く、建物名と階を軽くした重み
+func DefaultWeights() Weights {
+	return Weights{
+		PostalCode:   1,
+		Prefecture:   1,
+		Municipality: 2,
+		Town:         2,
+		Block:        2,
+		Building:     1,
+		Floor:        0.5,
+	}
+}
+
+// Similarity 構成要素毎の類似度の重み付き平均 [0, 1]
+// 片方にしか無い要素は比較しない. 郵便番号、都道府県、階は完全一致、
+// 市区町村、町域、建物名は正規化した文字列のLCSによる類似度、丁目、番地、号、部屋番号は両方にある数字の一致率で評価する
+func Similarity(a, b Address, w Weights) float32 {
+	var score, total float32
+
+	add := func(weight float32, x, y string, similarity func(x, y string) float32) {
+		if weight == 0 || x == "" || y == "" {
+			return
+		}
+
+		score += weight * similarity(x, y)
+		total += weight
+	}
+
+	add(w.PostalCode, a.PostalCode, b.PostalCode, equal)
+	add(w.Prefecture, a.Prefecture, b.Prefecture, equal)
+	add(w.Municipality, a.Municipality, b.Municipality, similar)
+	add(w.Town, a.Town, b.Town, similar)
+	add(w.Building, a.Building, b.Building, similar)
+	add(w.Floor, a.Floor, b.Floor, equal)
+
+	if block, ok := blockSimilarity(a, b); ok && w.Block != 0 {
+		score += w.Block * block
+		total += w.Block
+	}
+
+	if total == 0 {
+		return 0
+	}
+
+	return score / total
+}
+
+// Match 2つの住所の文字列を分解し、DefaultWeights の類似度がthreshold以上か判定する
+func Match(s, t string, threshold float32) (bool, float32) {
+	similarity := Similarity(Parse(s), Parse(t), DefaultWeights())
+
+	return threshold <= similarity, similarity
+}
+
+func equal(x, y string) float32 {
+	if x == y {
+		return 1
+	}
+
+	return 0
+}
+
+// LCSのサイズを2つの文字数の平均で割った類似度
+func similar(x, y string) float32 {
+	x, y = names.Normalize(x), names.Normalize(y)
+
+	length := len([]rune(x)) + len([]rune(y))
+	if length == 0 {
+		return 1
+	}
+
+	return float32(2*lcs.LcsBitParallel(x, y)) / float32(length)
+}
+
+// 両方にある丁目、番地、号、部屋番号の一致率. 比較できる数字が無ければfalse
+func blockSimilarity(a, b Address) (similarity float32, ok bool) {
+	var matched, compared int
+
+	for _, pair := range [][2]string{{a.Chome, b.Chome}, {a.Banchi, b.Banchi}, {a.Go, b.Go}, {a.Room, b.Room}} {
+		if pair[0] == "" || pair[1] == "" {
+			continue
+		}
+
+		compared++
+
+		if pair[0] == pair[1] {
+			matched++
+		}
+	}
+
+	if compared == 0 {
+		return 0, false
+	}
+
+	return float32(matched) / float32(compared), true
+}
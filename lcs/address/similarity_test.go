@@ -0,0 +1,51 @@
+package address_test
+
+import (
+	"lcs"
+	"lcs/address"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarity(t *testing.T) {
+	s1 := "〒106-0041東京都港区麻布台1丁目3-1麻布台ヒルズ森JPタワー 23F"
+
+	t.Run("same address in different notation", func(t *testing.T) {
+		ok, similarity := address.Match(s1, "東京都港区麻布台一丁目三番一号 麻布台ヒルズ森ＪＰタワー ２３階", 0.9)
+		assert.True(t, ok)
+		assert.Equal(t, float32(1), similarity)
+	})
+
+	t.Run("different block", func(t *testing.T) {
+		_, same := address.Match(s1, "東京都港区麻布台1-3-1", 0)
+		_, other := address.Match(s1, "東京都港区麻布台1-2-5", 0)
+		assert.Equal(t, float32(1), same)
+		assert.Less(t, other, same)
+
+		_, room := address.Match("東京都港区麻布台1-3-1-405", "東京都港区麻布台1-3-1-406", 0)
+		assert.Less(t, room, float32(1))
+	})
+
+	t.Run("false positive of character lcs", func(t *testing.T) {
+		// 文字単位のLCSでは一致とみなされる組も、構成要素毎に比べると区別できる
+		s2 := "東京都港区麻布十番1丁目3-1"
+		s3 := "東京都港区麻布台1丁目3-1"
+
+		_, lcsMatch := lcs.LCSMatch(s2, s1, 0)
+		assert.Less(t, float32(0.7), lcsMatch)
+
+		_, near := address.Match(s3, s1, 0)
+		_, far := address.Match(s2, s1, 0)
+		assert.Less(t, far, near)
+	})
+
+	t.Run("weights", func(t *testing.T) {
+		a := address.Parse("東京都港区麻布台1-3-1 森JPタワー")
+		b := address.Parse("東京都港区麻布台1-3-1 神谷町MTビル")
+
+		assert.Equal(t, float32(1), address.Similarity(a, b, address.Weights{Town: 1, Block: 1}))
+		assert.Less(t, address.Similarity(a, b, address.Weights{Town: 1, Building: 1}), float32(1))
+		assert.Equal(t, float32(0), address.Similarity(a, b, address.Weights{}))
+	})
+}
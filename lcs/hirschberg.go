@@ -0,0 +1,56 @@
+package lcs
+
+import "slices"
+
+// Hirschberg 最長共通部分列そのものを返す O(NM), メモリ O(N+M)
+// Sを半分に分け、前半の前向きと後半の後ろ向きのDPの行からTの分割位置を決めて再帰する
+func Hirschberg(s, t string) string {
+	runeS := []rune(s)
+
+	pairs := hirschberg(runeS, []rune(t), 0, 0)
+
+	common := make([]rune, len(pairs))
+	for k, pair := range pairs {
+		common[k] = runeS[pair.S]
+	}
+
+	return string(common)
+}
+
+// LCSの文字の位置の組を順に返す. offsetS, offsetTは元の文字列での先頭位置
+func hirschberg(runeS, runeT []rune, offsetS, offsetT int) []Pair {
+	switch {
+	case len(runeS) == 0 || len(runeT) == 0:
+		return nil
+	case len(runeS) == 1:
+		if j := slices.Index(runeT, runeS[0]); 0 <= j {
+			return []Pair{{S: offsetS, T: offsetT + j}}
+		}
+
+		return nil
+	}
+
+	mid := len(runeS) / 2
+
+	forward := lcsRow(runeS[:mid], runeT)
+	backward := lcsRow(reversed(runeS[mid:]), reversed(runeT))
+
+	// 前半と後半のLCSの和が最大となるTの分割位置
+	split, best := 0, -1
+
+	for j := range forward {
+		if size := forward[j] + backward[len(runeT)-j]; best < size {
+			split, best = j, size
+		}
+	}
+
+	return append(hirschberg(runeS[:mid], runeT[:split], offsetS, offsetT),
+		hirschberg(runeS[mid:], runeT[split:], offsetS+mid, offsetT+split)...)
+}
+
+func reversed(runes []rune) []rune {
+	runes = slices.Clone(runes)
+	slices.Reverse(runes)
+
+	return runes
+}
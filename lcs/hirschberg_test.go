@@ -0,0 +1,50 @@
+package lcs_test
+
+import (
+	"lcs"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// subがsの部分列か判定
+func isSubsequence(sub, s string) bool {
+	runes := []rune(sub)
+
+	for _, r := range s {
+		if 0 < len(runes) && runes[0] == r {
+			runes = runes[1:]
+		}
+	}
+
+	return len(runes) == 0
+}
+
+func TestHirschberg(t *testing.T) {
+	t.Run("get lcs string", func(t *testing.T) {
+		assert.Equal(t, "キノン", lcs.Hirschberg("キャノン", "キヤノン"))
+		assert.Equal(t, "xyz", lcs.Hirschberg("axayaaaaaz", "bbxbybz"))
+		assert.Equal(t, "麻布台ヒルズ", lcs.Hirschberg("麻布台ヒルズ", "〒106-0041東京都港区麻布台1丁目3-1麻布台ヒルズ森JPタワー 23F"))
+	})
+
+	t.Run("get lcs empty", func(t *testing.T) {
+		assert.Empty(t, lcs.Hirschberg("aaaaa", ""))
+		assert.Empty(t, lcs.Hirschberg("", "aaaaa"))
+		assert.Empty(t, lcs.Hirschberg("abc", "xyz"))
+	})
+
+	t.Run("common subsequence of maximum size", func(t *testing.T) {
+		r := rand.New(rand.NewSource(2))
+
+		for i := 0; i < 100; i++ {
+			s := randomString(r, []rune("acgt"), r.Intn(100))
+			u := randomString(r, []rune("acgt"), r.Intn(100))
+
+			result := lcs.Hirschberg(s, u)
+			assert.Len(t, []rune(result), lcs.LcsLength(s, u))
+			assert.True(t, isSubsequence(result, s))
+			assert.True(t, isSubsequence(result, u))
+		}
+	})
+}
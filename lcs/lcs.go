@@ -8,10 +8,17 @@ import (
 
 // Lcs 最長共通部分列のサイズを返す O(NM)
 // https://www.cs.t-kougei.ac.jp/SSys/LCS.htm
+//
+// Deprecated: (N+1)x(M+1)のテーブルを確保し、32767文字を超えるとサイズが桁あふれする. LcsLength または LcsBitParallel を使う
 func Lcs(s, t string) int16 {
 	runeS := []rune(s)
 	runeT := []rune(t)
 
+	return lcsTable(runeS, runeT)[len(runeS)][len(runeT)]
+}
+
+// LCSのDPテーブル. dp[i][j]はS[:i]とT[:j]のLCSのサイズ
+func lcsTable(runeS, runeT []rune) [][]int16 {
 	n, m := len(runeS), len(runeT)
 	dp := make([][]int16, n+1)
 	for i := 0; i < len(dp); i++ {
@@ -33,50 +40,140 @@ func Lcs(s, t string) int16 {
 		}
 	}
 
-	return dp[n][m]
+	return dp
 }
 
 type LocalAlignment struct {
 	MatchScore   int16
 	UnmatchScore int16
 	GapPenarty   int16
+
+	// アフィンギャップ (Gotoh). 長さkのギャップのペナルティは GapOpen + (k-1)*GapExtend
+	// 両方0の場合はGapPenartyを1文字毎のペナルティとする
+	GapOpen   int16
+	GapExtend int16
+
+	// Substitution 文字の組のスコア. nilの場合は一致でMatchScore、不一致で-UnmatchScore
+	// 例えば SubstitutionMatrix.Score を設定して、似た文字を部分的な一致として扱う
+	Substitution func(s, t rune) int16
 }
 
+// Smith-Watermanのスコア表. hは各セルの最大スコア、eとfは横方向と縦方向のギャップで終わる場合の最大スコア
+// 線形ギャップではeとfは直前のセルのhから求まるため確保しない (nil)
+type scoreTable struct {
+	h, e, f [][]int16
+}
+
+// ギャップが無いことを表すスコア. ペナルティを引いても桁あふれしない値とする
+const noGap = math.MinInt16 / 2
+
 func SmithWaterman(s, t string, a LocalAlignment) (lcs, maxLcs int16) {
 	runeS := []rune(s)
 	runeT := []rune(t)
 
+	table, maxLcs, _, _ := smithWatermanTable(runeS, runeT, a)
+
+	return table.h[len(runeS)][len(runeT)], maxLcs
+}
+
+// Smith-Watermanのスコア表. 最大スコアとそのセルの位置も返却する
+func smithWatermanTable(runeS, runeT []rune, a LocalAlignment) (table scoreTable, maxLcs int16, maxI, maxJ int) {
 	n, m := len(runeS), len(runeT)
-	dp := make([][]int16, n+1)
-	for i := 0; i < len(dp); i++ {
-		dp[i] = make([]int16, m+1)
+	open, extend := a.gapPenarties()
+	affine := open != extend
+
+	table.h = newMatrix(n+1, m+1, 0)
+	if affine {
+		table.e = newMatrix(n+1, m+1, noGap)
+		table.f = newMatrix(n+1, m+1, noGap)
 	}
 
+	h, e, f := table.h, table.e, table.f
+
 	for i := 0; i < n; i++ {
 		for j := 0; j < m; j++ {
-
-			var diagonalScore int16
-			// 一致した
-			if runeS[i] == runeT[j] {
-				diagonalScore = dp[i][j] + a.MatchScore
-			} else {
-				// 一致していない
-				diagonalScore = dp[i][j] - a.UnmatchScore
+			// 横方向と縦方向の遷移. ギャップを開く
+			horizontal, vertical := h[i+1][j]-open, h[i][j+1]-open
+
+			// アフィンギャップではギャップを延ばす遷移も比べる
+			if affine {
+				e[i+1][j+1] = max(horizontal, e[i+1][j]-extend)
+				f[i+1][j+1] = max(vertical, f[i][j+1]-extend)
+				horizontal, vertical = e[i+1][j+1], f[i+1][j+1]
 			}
 
-			dp[i+1][j+1] = max(
+			h[i+1][j+1] = max(
 				0,
-				diagonalScore,
-				dp[i][j+1]-a.GapPenarty, // 縦方向の遷移
-				dp[i+1][j]-a.GapPenarty, // 横方向の遷移
+				h[i][j]+a.score(runeS[i], runeT[j]),
+				horizontal,
+				vertical,
 			)
 
 			//　部分一致のLCSを評価するため最大のLCSを取得する
-			maxLcs = max(maxLcs, dp[i+1][j+1])
+			if maxLcs < h[i+1][j+1] {
+				maxLcs, maxI, maxJ = h[i+1][j+1], i+1, j+1
+			}
+		}
+	}
+
+	return
+}
+
+// 横方向のギャップで終わる場合の最大スコア
+func (table scoreTable) horizontal(i, j int, open int16) int16 {
+	if table.e == nil {
+		return table.h[i][j-1] - open
+	}
+
+	return table.e[i][j]
+}
+
+// 縦方向のギャップで終わる場合の最大スコア
+func (table scoreTable) vertical(i, j int, open int16) int16 {
+	if table.f == nil {
+		return table.h[i-1][j] - open
+	}
+
+	return table.f[i][j]
+}
+
+func newMatrix(n, m int, value int16) (matrix [][]int16) {
+	matrix = make([][]int16, n)
+	for i := range matrix {
+		matrix[i] = make([]int16, m)
+
+		if value != 0 {
+			for j := range matrix[i] {
+				matrix[i][j] = value
+			}
 		}
 	}
 
-	return dp[n][m], maxLcs
+	return
+}
+
+// ギャップを開くペナルティと延ばすペナルティ
+func (a LocalAlignment) gapPenarties() (open, extend int16) {
+	if a.GapOpen == 0 && a.GapExtend == 0 {
+		return a.GapPenarty, a.GapPenarty
+	}
+
+	return a.GapOpen, a.GapExtend
+}
+
+// 文字の組の対角方向の遷移のスコア
+func (a LocalAlignment) score(s, t rune) int16 {
+	if a.Substitution != nil {
+		return a.Substitution(s, t)
+	}
+
+	// 一致した
+	if s == t {
+		return a.MatchScore
+	}
+
+	// 一致していない
+	return -a.UnmatchScore
 }
 
 //nolint:gochecknoglobals
@@ -118,13 +215,22 @@ func ContainsEvaluate(substr, s string) int {
 	return prefixLen + suffixLen*2
 }
 
-func SmithWatermanMatch(substr, s string, threshold float32) (bool, float32) {
+// SmithWatermanMatch 局所整列のスコアをsの文字数で割った一致率がthreshold以上か判定する. sが空なら一致率は0
+// WithNormalizer を指定すると照合の前に両方の文字列を正規化する
+func SmithWatermanMatch(substr, s string, threshold float32, opts ...MatchOption) (bool, float32) {
+	substr, s = newMatchOption(opts).normalize(substr, s)
+
 	runeS := []rune(s)
 
 	// 空文字はスコアに無関係なので削除
 	s = strings.ReplaceAll(s, " ", "")
 	substr = strings.ReplaceAll(substr, " ", "")
 
+	// 正規化で空になった文字列は一致率を求められない
+	if len(runeS) == 0 {
+		return false, 0
+	}
+
 	_, maxLCS := SmithWaterman(substr, s, alignment)
 
 	match := float32(maxLCS) / float32(len(runeS))
@@ -132,10 +238,19 @@ func SmithWatermanMatch(substr, s string, threshold float32) (bool, float32) {
 	return threshold <= match, match
 }
 
-func LCSMatch(substr, s string, threshold float32) (bool, float32) {
+// LCSMatch LCSのサイズをsubstrの文字数で割った一致率がthreshold以上か判定する. substrが空なら一致率は0
+// WithNormalizer を指定すると照合の前に両方の文字列を正規化する
+func LCSMatch(substr, s string, threshold float32, opts ...MatchOption) (bool, float32) {
+	substr, s = newMatchOption(opts).normalize(substr, s)
+
 	runeS := []rune(substr)
 
-	size := Lcs(substr, s)
+	// 正規化で空になった文字列は一致率を求められない
+	if len(runeS) == 0 {
+		return false, 0
+	}
+
+	size := LcsBitParallel(substr, s)
 
 	match := float32(size) / float32(len(runeS))
 
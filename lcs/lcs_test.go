@@ -135,6 +135,45 @@ func TestSmithWaterman(t *testing.T) {
 	})
 }
 
+func TestSmithWatermanAffineGap(t *testing.T) {
+	s1 := "港区麻布台1丁目"
+	s2 := "港区麻布台ヒルズ森JPタワー1丁目"
+
+	t.Run("linear gap", func(t *testing.T) {
+		_, maxLcs := lcs.SmithWaterman(s1, s2, lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapPenarty: 1})
+		assert.Equal(t, int16(10), maxLcs)
+	})
+
+	t.Run("long gap costs less than scattered gaps", func(t *testing.T) {
+		a := lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapOpen: 3, GapExtend: 0}
+
+		_, maxLcs := lcs.SmithWaterman(s1, s2, a)
+		assert.Equal(t, int16(13), maxLcs)
+
+		// 1文字ずつのギャップは毎回開くペナルティがかかり、末尾の「1丁目」だけが残る
+		_, maxLcs = lcs.SmithWaterman("港区麻布台1丁目", "港x区x麻x布x台x1丁目", a)
+		assert.Equal(t, int16(6), maxLcs)
+
+		alignment := lcs.SmithWatermanAlign(s1, s2, a)
+		assert.Equal(t, 13, alignment.Score)
+		assert.Equal(t, "5=9D3=", alignment.Cigar)
+		assert.Equal(t, s2, s2[alignment.T.ByteStart:alignment.T.ByteEnd])
+	})
+
+	t.Run("same as linear gap", func(t *testing.T) {
+		linear := lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapPenarty: 1}
+		affine := lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapOpen: 1, GapExtend: 1}
+
+		for _, p := range [][2]string{{s1, s2}, {"京都駅", "梅小路京都西駅"}, {"abcxdef", "abcdef"}, {"axayaaaaaz", "bbxbybz"}} {
+			lcs1, max1 := lcs.SmithWaterman(p[0], p[1], linear)
+			lcs2, max2 := lcs.SmithWaterman(p[0], p[1], affine)
+			assert.Equal(t, lcs1, lcs2)
+			assert.Equal(t, max1, max2)
+			assert.Equal(t, lcs.SmithWatermanAlign(p[0], p[1], linear), lcs.SmithWatermanAlign(p[0], p[1], affine))
+		}
+	})
+}
+
 func TestPrefixContains(t *testing.T) {
 	t.Run("prefix string", func(t *testing.T) {
 		s1 := "渋谷駅"
@@ -261,6 +300,34 @@ func BenchmarkLCS(b *testing.B) {
 		}
 	})
 
+	b.Run("get lcs rolling row long different size", func(bSub *testing.B) {
+		bSub.ResetTimer()
+		for i := 0; i < bSub.N; i++ {
+			lcs.LcsLength(s1, s2)
+		}
+	})
+
+	b.Run("get lcs bit parallel long different size", func(bSub *testing.B) {
+		bSub.ResetTimer()
+		for i := 0; i < bSub.N; i++ {
+			lcs.LcsBitParallel(s1, s2)
+		}
+	})
+
+	b.Run("get lcs string long different size", func(bSub *testing.B) {
+		bSub.ResetTimer()
+		for i := 0; i < bSub.N; i++ {
+			_ = lcs.LcsAlign(s1, s2).Common
+		}
+	})
+
+	b.Run("get lcs string hirschberg long different size", func(bSub *testing.B) {
+		bSub.ResetTimer()
+		for i := 0; i < bSub.N; i++ {
+			lcs.Hirschberg(s1, s2)
+		}
+	})
+
 	b.Run("contains long different size", func(bSub *testing.B) {
 		bSub.ResetTimer()
 		for i := 0; i < bSub.N; i++ {
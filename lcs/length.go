@@ -0,0 +1,112 @@
+package lcs
+
+import "math/bits"
+
+// LcsLength 最長共通部分列のサイズを返す O(NM), メモリ O(min(N,M))
+// DPテーブルの直前の1行だけを保持する
+func LcsLength(s, t string) int {
+	runeS := []rune(s)
+	runeT := []rune(t)
+
+	// 短い方を列にして行を小さくする
+	if len(runeS) < len(runeT) {
+		runeS, runeT = runeT, runeS
+	}
+
+	row := lcsRow(runeS, runeT)
+
+	return row[len(runeT)]
+}
+
+// DPテーブルの最終行. row[j]はSとT[:j]のLCSのサイズ
+func lcsRow(runeS, runeT []rune) []int {
+	row := make([]int, len(runeT)+1)
+
+	for i := range runeS {
+		// diagonal: 更新前のrow[j] (dp[i][j])
+		diagonal := 0
+
+		for j := range runeT {
+			up := row[j+1]
+
+			if runeS[i] == runeT[j] {
+				row[j+1] = diagonal + 1
+			} else {
+				row[j+1] = max(up, row[j])
+			}
+
+			diagonal = up
+		}
+	}
+
+	return row
+}
+
+// LcsBitParallel 最長共通部分列のサイズをビット並列で返す O(N⌈M/64⌉)
+// Allison-Dix, Hyyröの方法. 短い方の文字列の各位置を1ビットとし、64文字ずつまとめて遷移させる
+func LcsBitParallel(s, t string) int {
+	runeS := []rune(s)
+	runeT := []rune(t)
+
+	if len(runeS) < len(runeT) {
+		runeS, runeT = runeT, runeS
+	}
+
+	m := len(runeT)
+	if m == 0 {
+		return 0
+	}
+
+	words := (m + 63) / 64
+
+	// 文字毎に出現位置のビットを立てる
+	masks := make(map[rune][]uint64)
+
+	for j, r := range runeT {
+		mask, ok := masks[r]
+		if !ok {
+			mask = make([]uint64, words)
+			masks[r] = mask
+		}
+
+		mask[j/64] |= 1 << (j % 64)
+	}
+
+	// vの0のビットがLCSに採用された位置を表す
+	v := make([]uint64, words)
+	for k := range v {
+		v[k] = ^uint64(0)
+	}
+
+	for _, r := range runeS {
+		mask, ok := masks[r]
+		if !ok {
+			continue
+		}
+
+		// V' = (V + U) | (V - U), U = V & M. UはVの部分集合なので V - U は V &^ U
+		var carry uint64
+
+		for k := range v {
+			u := v[k] & mask[k]
+
+			var sum uint64
+			sum, carry = bits.Add64(v[k], u, carry)
+
+			v[k] = sum | (v[k] &^ u)
+		}
+	}
+
+	length := 0
+
+	for k := range v {
+		valid := ^uint64(0)
+		if k == words-1 && m%64 != 0 {
+			valid = 1<<(m%64) - 1
+		}
+
+		length += bits.OnesCount64(^v[k] & valid)
+	}
+
+	return length
+}
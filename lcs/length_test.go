@@ -0,0 +1,67 @@
+package lcs_test
+
+import (
+	"lcs"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// alphabetの文字からなるランダムな文字列
+func randomString(r *rand.Rand, alphabet []rune, n int) string {
+	runes := make([]rune, n)
+	for i := range runes {
+		runes[i] = alphabet[r.Intn(len(alphabet))]
+	}
+
+	return string(runes)
+}
+
+func TestLcsLength(t *testing.T) {
+	t.Run("get lcs string size", func(t *testing.T) {
+		assert.Equal(t, 3, lcs.LcsLength("キャノン", "キヤノン"))
+		assert.Equal(t, 3, lcs.LcsBitParallel("キャノン", "キヤノン"))
+	})
+
+	t.Run("get lcs empty", func(t *testing.T) {
+		for _, fn := range []func(s, t string) int{lcs.LcsLength, lcs.LcsBitParallel} {
+			assert.Equal(t, 0, fn("aaaaa", ""))
+			assert.Equal(t, 0, fn("", "aaaaa"))
+			assert.Equal(t, 0, fn("", ""))
+		}
+	})
+
+	t.Run("get lcs different size", func(t *testing.T) {
+		for _, fn := range []func(s, t string) int{lcs.LcsLength, lcs.LcsBitParallel} {
+			assert.Equal(t, 3, fn("axayaaaaaz", "bbxbybz"))
+			assert.Equal(t, 3, fn("bbxbybz", "axayaaaaaz"))
+		}
+	})
+
+	t.Run("same as lcs", func(t *testing.T) {
+		r := rand.New(rand.NewSource(1))
+		alphabets := [][]rune{[]rune("ab"), []rune("acgt"), []rune("東京都港区麻布台渋谷駅")}
+
+		// 64文字の境界を跨ぐ長さを含める
+		for _, n := range []int{1, 10, 63, 64, 65, 127, 128, 129, 300} {
+			for _, alphabet := range alphabets {
+				s, u := randomString(r, alphabet, n), randomString(r, alphabet, r.Intn(2*n)+1)
+
+				expected := int(lcs.Lcs(s, u))
+				assert.Equal(t, expected, lcs.LcsLength(s, u))
+				assert.Equal(t, expected, lcs.LcsBitParallel(s, u))
+				assert.Equal(t, expected, lcs.LcsBitParallel(u, s))
+			}
+		}
+	})
+
+	t.Run("longer than int16", func(t *testing.T) {
+		s := strings.Repeat("ab", 20000)
+		u := strings.Repeat("a", 35000)
+
+		assert.Equal(t, 20000, lcs.LcsBitParallel(s, u))
+		assert.Equal(t, 35000, lcs.LcsBitParallel(u+"b", u))
+	})
+}
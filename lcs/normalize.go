@@ -0,0 +1,308 @@
+package lcs
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+type (
+	// Normalizer 照合前に文字列の表記揺れを統一する. 各項目は有効にしたものだけを適用する
+	Normalizer struct {
+		Width     bool           // NFKC. 全角英数記号を半角、半角カタカナを全角にし、丸数字や㈱等の互換文字を分解する. 単独の濁点も直前のかなに合成する
+		Space     bool           // タブや全角スペースを含む空白を削除する
+		Kana      KanaFolding    // ひらがなとカタカナの統一
+		SmallKana bool           // 小書きのかなを通常のかなにする (ャ → ヤ)
+		Dash      bool           // 長音符とハイフンの異体字を統一する. かなの後は「ー」、それ以外は「-」
+		Numeral   NumeralFolding // 漢数字と数字の統一
+	}
+
+	// KanaFolding ひらがなとカタカナの統一方向
+	KanaFolding int
+
+	// NumeralFolding 漢数字と数字の統一方向
+	NumeralFolding int
+
+	MatchOption func(*matchOption)
+
+	matchOption struct {
+		normalizer *Normalizer
+	}
+)
+
+const (
+	KanaAsIs KanaFolding = iota
+	// ToKatakana ひらがなをカタカナにする
+	ToKatakana
+	// ToHiragana カタカナをひらがなにする
+	ToHiragana
+)
+
+const (
+	NumeralAsIs NumeralFolding = iota
+	// ToDigit 漢数字を数字にする. 十百千を含む場合は位取りして読む (三十二 → 32)
+	// 地名の一部を変えないよう、助数詞 (丁目、番、号、階、地割) が続くか後ろに文字が続かない漢数字だけを変換する (千代田はそのまま)
+	ToDigit
+	// ToKanji 数字を1桁ずつ漢数字にする (32 → 三二)
+	ToKanji
+)
+
+//nolint:gochecknoglobals
+var (
+	// 長音符またはハイフンとして使われる文字
+	dashes = []rune("-‐‑‒–—―−－ー")
+
+	kanjiDigits = []rune("〇一二三四五六七八九")
+
+	kanjiUnits = map[rune]int{'十': 10, '百': 100, '千': 1000}
+
+	// 漢数字を変換する助数詞
+	counters = []string{"丁目", "番", "号", "階", "地割"}
+)
+
+// DefaultNormalizer 住所や施設名の照合向けに全ての項目を有効にした設定
+func DefaultNormalizer() *Normalizer {
+	return &Normalizer{
+		Width:     true,
+		Space:     true,
+		Kana:      ToKatakana,
+		SmallKana: true,
+		Dash:      true,
+		Numeral:   ToDigit,
+	}
+}
+
+// WithNormalizer 照合の前に両方の文字列をnで正規化する
+func WithNormalizer(n *Normalizer) MatchOption {
+	return func(o *matchOption) {
+		o.normalizer = n
+	}
+}
+
+func newMatchOption(opts []MatchOption) (o *matchOption) {
+	o = new(matchOption)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return
+}
+
+// 正規化の設定があれば両方の文字列を正規化する
+func (o *matchOption) normalize(substr, s string) (string, string) {
+	if o.normalizer == nil {
+		return substr, s
+	}
+
+	return o.normalizer.Normalize(substr), o.normalizer.Normalize(s)
+}
+
+// Normalize 文字列を正規化する
+// 幅、空白、かな、小書き、長音符とハイフン、漢数字の順に適用する
+func (n *Normalizer) Normalize(s string) string {
+	runes := []rune(s)
+
+	if n.Width {
+		runes = foldWidth(runes)
+	}
+
+	if n.Space {
+		runes = removeSpace(runes)
+	}
+
+	for i, r := range runes {
+		switch {
+		case n.Kana == ToKatakana && ('ぁ' <= r && r <= 'ゖ' || r == 'ゝ' || r == 'ゞ'):
+			r += hiraganaOffset
+		case n.Kana == ToHiragana && ('ァ' <= r && r <= 'ヶ' || r == 'ヽ' || r == 'ヾ'):
+			r -= hiraganaOffset
+		}
+
+		if n.SmallKana {
+			r = largeKana(r)
+		}
+
+		runes[i] = r
+	}
+
+	if n.Dash {
+		foldDash(runes)
+	}
+
+	switch n.Numeral {
+	case ToDigit:
+		runes = kanjiToDigit(runes)
+	case ToKanji:
+		for i, r := range runes {
+			if '0' <= r && r <= '9' {
+				runes[i] = kanjiDigits[r-'0']
+			}
+		}
+	}
+
+	return string(runes)
+}
+
+// NFKCで正規化する. 単独の濁点と半濁点はNFKCでは空白と結合文字に分解されるため、先に結合文字にして直前のかなと合成する
+// 合成できずに残った結合文字は「゛」「゜」に戻す
+func foldWidth(runes []rune) []rune {
+	for i, r := range runes {
+		switch r {
+		case '゛':
+			runes[i] = '\u3099'
+		case '゜':
+			runes[i] = '\u309a'
+		}
+	}
+
+	runes = []rune(norm.NFKC.String(string(runes)))
+
+	for i, r := range runes {
+		switch r {
+		case '\u3099':
+			runes[i] = '゛'
+		case '\u309a':
+			runes[i] = '゜'
+		}
+	}
+
+	return runes
+}
+
+// タブ、改行、全角スペース等の空白を削除する
+func removeSpace(runes []rune) (result []rune) {
+	result = runes[:0]
+
+	for _, r := range runes {
+		if !unicode.IsSpace(r) {
+			result = append(result, r)
+		}
+	}
+
+	return
+}
+
+// 小書きのかなを通常のかなにする
+func largeKana(r rune) rune {
+	for _, pair := range smallKana {
+		switch r {
+		case pair[0]:
+			return pair[1]
+		case pair[0] - hiraganaOffset:
+			return pair[1] - hiraganaOffset
+		}
+	}
+
+	return r
+}
+
+// 直前がかなの長音符とハイフンの異体字は「ー」、それ以外は「-」にする
+func foldDash(runes []rune) {
+	for i, r := range runes {
+		if !slices.Contains(dashes, r) {
+			continue
+		}
+
+		if 0 < i && isKana(runes[i-1]) {
+			runes[i] = 'ー'
+		} else {
+			runes[i] = '-'
+		}
+	}
+}
+
+func isKana(r rune) bool {
+	return unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r) || r == 'ー'
+}
+
+// 連続する漢数字を数字にする. 後ろに助数詞以外の文字が続く場合は地名の一部として残す
+func kanjiToDigit(runes []rune) (result []rune) {
+	result = make([]rune, 0, len(runes))
+
+	for start := 0; start < len(runes); {
+		end := start
+		for end < len(runes) && isKanjiNumeral(runes[end]) {
+			end++
+		}
+
+		if end == start {
+			result = append(result, runes[start])
+			start++
+
+			continue
+		}
+
+		if numeralEnds(runes[end:]) {
+			result = append(result, []rune(readKanjiNumeral(runes[start:end]))...)
+		} else {
+			result = append(result, runes[start:end]...)
+		}
+
+		start = end
+	}
+
+	return
+}
+
+// 漢数字の後ろが助数詞か文字以外(末尾を含む)か判定
+func numeralEnds(rest []rune) bool {
+	if len(rest) == 0 || !unicode.IsLetter(rest[0]) {
+		return true
+	}
+
+	// 助数詞は2文字まで
+	head := string(rest[:min(len(rest), 2)])
+
+	for _, counter := range counters {
+		if strings.HasPrefix(head, counter) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isKanjiNumeral(r rune) bool {
+	_, unit := kanjiUnits[r]
+
+	return unit || slices.Contains(kanjiDigits, r)
+}
+
+// 漢数字を読む. 十百千を含まなければ1桁ずつ数字にする (二〇二四 → 2024)
+func readKanjiNumeral(runes []rune) string {
+	digits := make([]rune, len(runes))
+	positional := false
+
+	for i, r := range runes {
+		if _, ok := kanjiUnits[r]; ok {
+			positional = true
+			break
+		}
+
+		digits[i] = '0' + rune(slices.Index(kanjiDigits, r))
+	}
+
+	if !positional {
+		return string(digits)
+	}
+
+	// 位取り: 十の前に数字が無ければ1とする (十二 → 12, 二百五 → 205)
+	total, digit := 0, -1
+
+	for _, r := range runes {
+		if unit, ok := kanjiUnits[r]; ok {
+			total += max(digit, 1) * unit
+			digit = -1
+
+			continue
+		}
+
+		digit = max(digit, 0)*10 + slices.Index(kanjiDigits, r)
+	}
+
+	return strconv.Itoa(total + max(digit, 0))
+}
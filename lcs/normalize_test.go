@@ -0,0 +1,109 @@
+package lcs_test
+
+import (
+	"lcs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizer(t *testing.T) {
+	t.Run("width", func(t *testing.T) {
+		n := &lcs.Normalizer{Width: true}
+		assert.Equal(t, "〒106-0041 ABC", n.Normalize("〒１０６－００４１　ＡＢＣ"))
+		assert.Equal(t, "ガンダムパーク。", n.Normalize("ｶﾞﾝﾀﾞﾑﾊﾟｰｸ｡"))
+		assert.Equal(t, "ガ", n.Normalize("ガ"))
+		assert.Equal(t, "ヴ", n.Normalize("ｳﾞ"))
+		assert.Equal(t, "ア゛", n.Normalize("ｱﾞ"))
+		assert.Equal(t, "1-20-(3)", n.Normalize("①-⑳-⑶"))
+		assert.Equal(t, "(株)キヤノン", n.Normalize("㈱キヤノン"))
+		assert.Equal(t, "平成31年", n.Normalize("㍻31年"))
+		assert.Equal(t, "¥100", n.Normalize("￥１００"))
+		assert.Equal(t, "ガゔ゛", n.Normalize("カ゛う゛゛"))
+		assert.Equal(t, "ミリ", n.Normalize("㍉"))
+		assert.Equal(t, "ヲァィゥェォャュョッーアイウエオカキクケコサシスセソタチツテトナニヌネノハヒフヘホマミムメモヤユヨラリルレロワン゛", n.Normalize("ｦｧｨｩｪｫｬｭｮｯｰｱｲｳｴｵｶｷｸｹｺｻｼｽｾｿﾀﾁﾂﾃﾄﾅﾆﾇﾈﾉﾊﾋﾌﾍﾎﾏﾐﾑﾒﾓﾔﾕﾖﾗﾘﾙﾚﾛﾜﾝﾞ"))
+	})
+
+	t.Run("space", func(t *testing.T) {
+		n := &lcs.Normalizer{Space: true}
+		assert.Equal(t, "東京都港区麻布台", n.Normalize(" 東京都\t港区　麻布台\n"))
+	})
+
+	t.Run("kana", func(t *testing.T) {
+		assert.Equal(t, "キャノンヽ", (&lcs.Normalizer{Kana: lcs.ToKatakana}).Normalize("きゃのんゝ"))
+		assert.Equal(t, "きゃのん漢字", (&lcs.Normalizer{Kana: lcs.ToHiragana}).Normalize("キャノン漢字"))
+		assert.Equal(t, "キヤノン", (&lcs.Normalizer{SmallKana: true}).Normalize("キャノン"))
+		assert.Equal(t, "やつけ", (&lcs.Normalizer{SmallKana: true}).Normalize("ゃっゖ"))
+	})
+
+	t.Run("dash", func(t *testing.T) {
+		n := &lcs.Normalizer{Dash: true}
+		assert.Equal(t, "106-0041", n.Normalize("106ー0041"))
+		assert.Equal(t, "3-1", n.Normalize("3−1"))
+		assert.Equal(t, "パーク", n.Normalize("パ－ク"))
+		assert.Equal(t, "コーーヒー", n.Normalize("コ—―ヒ‐"))
+	})
+
+	t.Run("numeral", func(t *testing.T) {
+		n := &lcs.Normalizer{Numeral: lcs.ToDigit}
+		assert.Equal(t, "1丁目3番地", n.Normalize("一丁目三番地"))
+		assert.Equal(t, "12丁目205番", n.Normalize("十二丁目二百五番"))
+		assert.Equal(t, "2024-32", n.Normalize("二〇二四-三十二"))
+		assert.Equal(t, "1110", n.Normalize("千百十"))
+		assert.Equal(t, "5階B3", n.Normalize("五階B三"))
+		assert.Equal(t, "二〇二四年", n.Normalize("二〇二四年"))
+	})
+
+	t.Run("place names", func(t *testing.T) {
+		n := &lcs.Normalizer{Numeral: lcs.ToDigit}
+		assert.Equal(t, "千代田区1番町", n.Normalize("千代田区一番町"))
+		assert.Equal(t, "九段下", n.Normalize("九段下"))
+		assert.Equal(t, "八王子市", n.Normalize("八王子市"))
+		assert.Equal(t, "六本木6丁目", n.Normalize("六本木六丁目"))
+		assert.Equal(t, "十条駅", n.Normalize("十条駅"))
+		assert.Equal(t, "九条4丁目", n.Normalize("九条四丁目"))
+		assert.Equal(t, "三二丁目", (&lcs.Normalizer{Numeral: lcs.ToKanji}).Normalize("32丁目"))
+	})
+
+	t.Run("default", func(t *testing.T) {
+		n := lcs.DefaultNormalizer()
+		assert.Equal(t, n.Normalize("〒106-0041 東京都港区麻布台1丁目3-1"), n.Normalize("〒１０６－００４１　東京都港区麻布台一丁目３ー１"))
+		assert.Equal(t, n.Normalize("キヤノン"), n.Normalize("きゃのん"))
+		assert.Empty(t, n.Normalize(""))
+	})
+}
+
+func TestMatchWithNormalizer(t *testing.T) {
+	normalizer := lcs.WithNormalizer(lcs.DefaultNormalizer())
+
+	t.Run("lcs match", func(t *testing.T) {
+		_, match := lcs.LCSMatch("キャノン", "キヤノン", 0.7)
+		assert.Equal(t, float32(0.75), match)
+
+		ok, match := lcs.LCSMatch("キャノン", "キヤノン", 1, normalizer)
+		assert.True(t, ok)
+		assert.Equal(t, float32(1), match)
+	})
+
+	t.Run("smith waterman match", func(t *testing.T) {
+		s1 := "麻布台一丁目"
+		s2 := "〒106-0041 東京都港区麻布台１丁目"
+
+		_, before := lcs.SmithWatermanMatch(s1, s2, 0)
+		_, after := lcs.SmithWatermanMatch(s1, s2, 0, normalizer)
+		assert.Less(t, before, after)
+
+		ok, _ := lcs.SmithWatermanMatch("麻布台1丁目", "麻布台\t一丁目", 1, normalizer)
+		assert.True(t, ok)
+	})
+
+	t.Run("empty after normalize", func(t *testing.T) {
+		ok, match := lcs.LCSMatch(" \t", "麻布台", 0, normalizer)
+		assert.False(t, ok)
+		assert.Zero(t, match)
+
+		ok, match = lcs.SmithWatermanMatch("麻布台", "　", 0, normalizer)
+		assert.False(t, ok)
+		assert.Zero(t, match)
+	})
+}
@@ -0,0 +1,65 @@
+package lcs
+
+// SubstitutionMatrix 文字の組毎のスコア表. 登録の無い組は一致でMatch、不一致で-Unmatchとする
+// LocalAlignment.Substitution に Score を設定して使う
+type SubstitutionMatrix struct {
+	Match   int16
+	Unmatch int16
+	scores  map[[2]rune]int16
+}
+
+// 小書き文字と対応する文字の組. ひらがなはカタカナから求める
+//
+//nolint:gochecknoglobals
+var smallKana = [][2]rune{
+	{'ァ', 'ア'}, {'ィ', 'イ'}, {'ゥ', 'ウ'}, {'ェ', 'エ'}, {'ォ', 'オ'},
+	{'ッ', 'ツ'}, {'ャ', 'ヤ'}, {'ュ', 'ユ'}, {'ョ', 'ヨ'}, {'ヮ', 'ワ'},
+	{'ヵ', 'カ'}, {'ヶ', 'ケ'},
+}
+
+const (
+	// カタカナとひらがなのコードポイントの差
+	hiraganaOffset = 'ア' - 'あ'
+	// 全角英数記号と半角のコードポイントの差
+	fullWidthOffset = '！' - '!'
+)
+
+func NewSubstitutionMatrix(match, unmatch int16) *SubstitutionMatrix {
+	return &SubstitutionMatrix{Match: match, Unmatch: unmatch, scores: make(map[[2]rune]int16)}
+}
+
+// JapaneseVariants 表記揺れになりやすい文字の組をnearのスコアで登録した表
+// 小書きのかなと通常のかな (ャとヤ等)、全角と半角の英数記号 (１と1等)
+func JapaneseVariants(match, unmatch, near int16) *SubstitutionMatrix {
+	m := NewSubstitutionMatrix(match, unmatch)
+
+	for _, pair := range smallKana {
+		m.Set(pair[0], pair[1], near)
+		m.Set(pair[0]-hiraganaOffset, pair[1]-hiraganaOffset, near)
+	}
+
+	for r := '!'; r <= '~'; r++ {
+		m.Set(r, r+fullWidthOffset, near)
+	}
+
+	return m
+}
+
+// Set 文字の組のスコアを登録する. 順序は問わない
+func (m *SubstitutionMatrix) Set(s, t rune, score int16) {
+	m.scores[[2]rune{s, t}] = score
+	m.scores[[2]rune{t, s}] = score
+}
+
+// Score 文字の組のスコア
+func (m *SubstitutionMatrix) Score(s, t rune) int16 {
+	if score, ok := m.scores[[2]rune{s, t}]; ok {
+		return score
+	}
+
+	if s == t {
+		return m.Match
+	}
+
+	return -m.Unmatch
+}
@@ -0,0 +1,43 @@
+package lcs_test
+
+import (
+	"lcs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubstitutionMatrix(t *testing.T) {
+	m := lcs.JapaneseVariants(2, 1, 1)
+
+	t.Run("score", func(t *testing.T) {
+		assert.Equal(t, int16(2), m.Score('ヤ', 'ヤ'))
+		assert.Equal(t, int16(1), m.Score('ャ', 'ヤ'))
+		assert.Equal(t, int16(1), m.Score('ヤ', 'ャ'))
+		assert.Equal(t, int16(1), m.Score('ゃ', 'や'))
+		assert.Equal(t, int16(1), m.Score('ゕ', 'か'))
+		assert.Equal(t, int16(1), m.Score('１', '1'))
+		assert.Equal(t, int16(1), m.Score('Ａ', 'A'))
+		assert.Equal(t, int16(1), m.Score('－', '-'))
+		assert.Equal(t, int16(-1), m.Score('ャ', 'ユ'))
+
+		m.Set('ヴ', 'ブ', 1)
+		assert.Equal(t, int16(1), m.Score('ブ', 'ヴ'))
+	})
+
+	t.Run("near match in smith waterman", func(t *testing.T) {
+		flat := lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapPenarty: 2}
+		near := flat
+		near.Substitution = m.Score
+
+		_, flatScore := lcs.SmithWaterman("キャノン", "キヤノン", flat)
+		_, nearScore := lcs.SmithWaterman("キャノン", "キヤノン", near)
+		assert.Equal(t, int16(5), flatScore)
+		assert.Equal(t, int16(7), nearScore)
+
+		a := lcs.SmithWatermanAlign("港区麻布台１丁目", "港区麻布台1丁目", near)
+		assert.Equal(t, 15, a.Score)
+		assert.Equal(t, "5=1X2=", a.Cigar)
+		assert.Equal(t, "港区麻布台丁目", a.Common)
+	})
+}
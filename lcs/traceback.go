@@ -0,0 +1,187 @@
+package lcs
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type (
+	// Alignment 整列の結果. 位置はrune単位
+	Alignment struct {
+		Score  int    // LcsAlignではLCSのサイズ、SmithWatermanAlignでは局所整列のスコア
+		Common string // 一致した文字を順に並べた文字列. LcsAlignではLCSそのもの
+		Pairs  []Pair // 一致した文字の位置の組
+		S, T   Span   // 整列した範囲
+		Cigar  string // 整列の編集操作. 例: "3=1X2I"
+	}
+
+	// Pair 一致した文字のSとTでの位置
+	Pair struct {
+		S, T int
+	}
+
+	// Span 入力中の範囲 [Start, End). Byte付きはbyte単位の位置で、元の文字列のスライスに使える
+	Span struct {
+		Start, End         int
+		ByteStart, ByteEnd int
+	}
+)
+
+// CIGARの編集操作
+const (
+	OpMatch    = '=' // 一致
+	OpMismatch = 'X' // 不一致
+	OpInsert   = 'I' // Sのみの文字
+	OpDelete   = 'D' // Tのみの文字
+)
+
+// LcsAlign 最長共通部分列とその整列を返す O(NM), メモリ O(N+M)
+// Hirschberg で一致した文字の位置を求める. 整列の範囲は両方の文字列全体とし、一致しない文字はI/Dで表す
+func LcsAlign(s, t string) (alignment Alignment) {
+	runeS := []rune(s)
+	runeT := []rune(t)
+
+	alignment.Pairs = hirschberg(runeS, runeT, 0, 0)
+
+	var ops []byte
+
+	// 一致した文字の間は、Tのみの文字、Sのみの文字の順に並べる
+	gap := func(i, j, endS, endT int) {
+		ops = append(ops, bytes.Repeat([]byte{OpDelete}, endT-j)...)
+		ops = append(ops, bytes.Repeat([]byte{OpInsert}, endS-i)...)
+	}
+
+	i, j := 0, 0
+
+	for _, pair := range alignment.Pairs {
+		gap(i, j, pair.S, pair.T)
+		ops = append(ops, OpMatch)
+		i, j = pair.S+1, pair.T+1
+	}
+
+	gap(i, j, len(runeS), len(runeT))
+
+	alignment.Score = len(alignment.Pairs)
+	alignment.finish(s, t, runeS, ops, 0, len(runeS), 0, len(runeT))
+
+	return
+}
+
+// SmithWatermanAlign 最大スコアの局所整列を返す
+// Substitution で正のスコアを持つ異なる文字の組は、Pairsには含めずCIGARでは不一致として表す
+// 最大スコアのセルが複数ある場合は SmithWaterman と同じく最初に見つかったものを用いる
+func SmithWatermanAlign(s, t string, a LocalAlignment) (alignment Alignment) {
+	runeS := []rune(s)
+	runeT := []rune(t)
+
+	table, maxLcs, i, j := smithWatermanTable(runeS, runeT, a)
+	endS, endT := i, j
+
+	h := table.h
+	open, _ := a.gapPenarties()
+
+	var ops []byte
+
+	// 辿っている表. OpInsertならf、OpDeleteならe、0ならh
+	var state byte
+
+	// スコアが0になるまで遷移元を辿る
+	for 0 < i && 0 < j && (state != 0 || 0 < h[i][j]) {
+		switch {
+		case state == OpInsert:
+			// ギャップを開いた位置ならhに戻る
+			if table.vertical(i, j, open) == h[i-1][j]-open {
+				state = 0
+			}
+
+			ops = append(ops, OpInsert)
+			i--
+		case state == OpDelete:
+			if table.horizontal(i, j, open) == h[i][j-1]-open {
+				state = 0
+			}
+
+			ops = append(ops, OpDelete)
+			j--
+		case h[i][j] == h[i-1][j-1]+a.score(runeS[i-1], runeT[j-1]):
+			if runeS[i-1] == runeT[j-1] {
+				alignment.Pairs = append(alignment.Pairs, Pair{S: i - 1, T: j - 1})
+				ops = append(ops, OpMatch)
+			} else {
+				ops = append(ops, OpMismatch)
+			}
+
+			i, j = i-1, j-1
+		case h[i][j] == table.vertical(i, j, open):
+			state = OpInsert
+		default:
+			state = OpDelete
+		}
+	}
+
+	// 末尾から辿ったので正順に直す
+	slices.Reverse(alignment.Pairs)
+	slices.Reverse(ops)
+
+	alignment.Score = int(maxLcs)
+	alignment.finish(s, t, runeS, ops, i, endS, j, endT)
+
+	return
+}
+
+// 一致した文字、範囲とCIGARを設定する
+func (alignment *Alignment) finish(s, t string, runeS []rune, ops []byte, startS, endS, startT, endT int) {
+	common := make([]rune, len(alignment.Pairs))
+	for k, pair := range alignment.Pairs {
+		common[k] = runeS[pair.S]
+	}
+
+	alignment.Common = string(common)
+	alignment.S = newSpan(s, startS, endS)
+	alignment.T = newSpan(t, startT, endT)
+	alignment.Cigar = cigar(ops)
+}
+
+// rune単位の範囲にbyte単位の位置を加える
+func newSpan(s string, start, end int) (span Span) {
+	span.Start, span.End = start, end
+	span.ByteStart, span.ByteEnd = len(s), len(s)
+
+	count := 0
+
+	for offset := range s {
+		if count == start {
+			span.ByteStart = offset
+		}
+
+		if count == end {
+			span.ByteEnd = offset
+			break
+		}
+
+		count++
+	}
+
+	return
+}
+
+// 編集操作を連長圧縮する
+func cigar(ops []byte) string {
+	var builder strings.Builder
+
+	for start := 0; start < len(ops); {
+		end := start
+		for end < len(ops) && ops[end] == ops[start] {
+			end++
+		}
+
+		builder.WriteString(strconv.Itoa(end - start))
+		builder.WriteByte(ops[start])
+
+		start = end
+	}
+
+	return builder.String()
+}
@@ -0,0 +1,128 @@
+package lcs_test
+
+import (
+	"lcs"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLcsAlign(t *testing.T) {
+	t.Run("get lcs string", func(t *testing.T) {
+		a := lcs.LcsAlign("キャノン", "キヤノン")
+		assert.Equal(t, 3, a.Score)
+		assert.Equal(t, "キノン", a.Common)
+		assert.Equal(t, []lcs.Pair{{S: 0, T: 0}, {S: 2, T: 2}, {S: 3, T: 3}}, a.Pairs)
+		assert.Equal(t, "1=1D1I2=", a.Cigar)
+		assert.Equal(t, lcs.Span{Start: 0, End: 4, ByteStart: 0, ByteEnd: 12}, a.S)
+	})
+
+	t.Run("get lcs different size", func(t *testing.T) {
+		a := lcs.LcsAlign("axayaaaaaz", "bbxbybz")
+		assert.Equal(t, 3, a.Score)
+		assert.Equal(t, "xyz", a.Common)
+		assert.Equal(t, "2D1I1=1D1I1=1D5I1=", a.Cigar)
+	})
+
+	t.Run("get lcs empty", func(t *testing.T) {
+		a := lcs.LcsAlign("aaaaa", "")
+		assert.Equal(t, 0, a.Score)
+		assert.Empty(t, a.Common)
+		assert.Empty(t, a.Pairs)
+		assert.Equal(t, "5I", a.Cigar)
+
+		a = lcs.LcsAlign("", "")
+		assert.Empty(t, a.Cigar)
+	})
+
+	t.Run("longer than int16", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("O(NM)")
+		}
+
+		s := strings.Repeat("ab", 16500)
+
+		a := lcs.LcsAlign(s, s)
+		assert.Equal(t, 33000, a.Score)
+		assert.Equal(t, "33000=", a.Cigar)
+	})
+
+	t.Run("consistent with lcs", func(t *testing.T) {
+		pairs := [][2]string{
+			{"麻布台ヒルズ", "〒106-0041東京都港区麻布台1丁目3-1麻布台ヒルズ森JPタワー 23F"},
+			{"セルフィスタ渋谷", "インドア ゴルフレッスンスタジオ渋谷"},
+			{"abcbdab", "bdcaba"},
+		}
+
+		for _, p := range pairs {
+			a := lcs.LcsAlign(p[0], p[1])
+			assert.Equal(t, lcs.LcsLength(p[0], p[1]), a.Score)
+			assert.Len(t, []rune(a.Common), a.Score)
+
+			s, u := []rune(p[0]), []rune(p[1])
+			for k, pair := range a.Pairs {
+				assert.Equal(t, s[pair.S], u[pair.T])
+
+				if 0 < k {
+					assert.Less(t, a.Pairs[k-1].S, pair.S)
+					assert.Less(t, a.Pairs[k-1].T, pair.T)
+				}
+			}
+		}
+	})
+}
+
+func TestSmithWatermanAlign(t *testing.T) {
+	l := lcs.LocalAlignment{
+		MatchScore:   1,
+		UnmatchScore: 1,
+		GapPenarty:   1,
+	}
+
+	t.Run("matched span of address", func(t *testing.T) {
+		s1 := "麻布台ヒルズ"
+		s2 := "〒106-0041東京都港区麻布台1丁目3-1麻布台ヒルズ森JPタワー 23F"
+
+		a := lcs.SmithWatermanAlign(s1, s2, l)
+		assert.Equal(t, 6, a.Score)
+		assert.Equal(t, "麻布台ヒルズ", a.Common)
+		assert.Equal(t, "6=", a.Cigar)
+		assert.Equal(t, lcs.Span{Start: 0, End: 6, ByteStart: 0, ByteEnd: 18}, a.S)
+		assert.Equal(t, 23, a.T.Start)
+		assert.Equal(t, 29, a.T.End)
+		assert.Equal(t, "麻布台ヒルズ", s2[a.T.ByteStart:a.T.ByteEnd])
+	})
+
+	t.Run("mismatch and gap", func(t *testing.T) {
+		a := lcs.SmithWatermanAlign("京都駅", "梅小路京都西駅", l)
+		assert.Equal(t, 2, a.Score)
+		assert.Equal(t, "京都", a.Common)
+		assert.Equal(t, "2=", a.Cigar)
+
+		a = lcs.SmithWatermanAlign("abcxdef", "abcdef", lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapPenarty: 1})
+		assert.Equal(t, 11, a.Score)
+		assert.Equal(t, "abcdef", a.Common)
+		assert.Equal(t, "3=1I3=", a.Cigar)
+
+		a = lcs.SmithWatermanAlign("abcxef", "abcdef", lcs.LocalAlignment{MatchScore: 2, UnmatchScore: 1, GapPenarty: 2})
+		assert.Equal(t, 9, a.Score)
+		assert.Equal(t, "3=1X2=", a.Cigar)
+		assert.Equal(t, []lcs.Pair{{0, 0}, {1, 1}, {2, 2}, {4, 4}, {5, 5}}, a.Pairs)
+	})
+
+	t.Run("consistent with smith waterman", func(t *testing.T) {
+		for _, p := range [][2]string{{"京都駅", "レグゼスタ京都駅西"}, {"京都駅", "京都駅西ビル"}, {"abc", "xyz"}} {
+			_, maxLcs := lcs.SmithWaterman(p[0], p[1], l)
+			assert.Equal(t, int(maxLcs), lcs.SmithWatermanAlign(p[0], p[1], l).Score)
+		}
+	})
+
+	t.Run("no alignment", func(t *testing.T) {
+		a := lcs.SmithWatermanAlign("abc", "xyz", l)
+		assert.Equal(t, 0, a.Score)
+		assert.Empty(t, a.Cigar)
+		assert.Empty(t, a.Pairs)
+		assert.Equal(t, lcs.Span{}, a.S)
+	})
+}
@@ -0,0 +1,169 @@
+package rtree
+
+// InsertBatch 複数のリーフエントリーをまとめて挿入する
+// エントリーを挿入先の部分木毎に振り分けて一度に追加し、分割と短形の調整は影響を受けたノード毎に一度だけ行う
+// 検査で誤りがあれば木は変更しない. R*の強制再挿入は行わず、分割のみで調整する
+func (tree *RTree) InsertBatch(entries Nodes) (err error) {
+	seen := make(map[uint64]bool, len(entries))
+
+	for _, entry := range entries {
+		if !tree.validEntry(entry.Rectangle) {
+			return ErrInvalidRectangle
+		}
+
+		if entry.DataID == nil {
+			continue
+		}
+
+		if _, ok := tree.entries[*entry.DataID]; ok || seen[*entry.DataID] {
+			return ErrDuplicateID
+		}
+
+		seen[*entry.DataID] = true
+	}
+
+	if len(entries) == 0 {
+		return
+	}
+
+	siblings := tree.insertBatch(tree.Root, entries)
+
+	// ルートが分割されたら1ノードに収まるまで上の階層を作る
+	for 1 < len(siblings) {
+		root := tree.NewNode(nil)
+
+		for _, sibling := range siblings {
+			root.AddEntry(sibling)
+		}
+
+		root.AdjustCoverRectangles()
+		tree.Root = root
+
+		siblings = tree.divide(root)
+	}
+
+	for _, entry := range entries {
+		if entry.DataID != nil {
+			tree.entries[*entry.DataID] = entry
+		}
+	}
+
+	return
+}
+
+// node配下にエントリーを挿入し、nodeと分割で生じた兄弟ノードを返却する
+func (tree *RTree) insertBatch(node *Node, entries Nodes) (siblings Nodes) {
+	if node.isLeaf() {
+		for _, entry := range entries {
+			node.AddEntry(entry)
+		}
+
+		node.AdjustCoverRectangles()
+
+		return tree.divide(node)
+	}
+
+	// 挿入先の子毎に振り分ける. 逐次挿入と同様に、選んだ子の短形を広げてから次のエントリーを選ぶ
+	children := make(Nodes, len(node.Children))
+	copy(children, node.Children)
+
+	groups := make(map[*Node]Nodes)
+
+	for _, entry := range entries {
+		child := children.chooseSubtree(tree.cnf, entry.Rectangle)
+		child.Rectangle.extend(entry.Rectangle)
+		groups[child] = append(groups[child], entry)
+	}
+
+	for _, child := range children {
+		if group, ok := groups[child]; ok {
+			for _, sibling := range tree.insertBatch(child, group)[1:] {
+				node.AddEntry(sibling)
+			}
+		}
+	}
+
+	node.AdjustCoverRectangles()
+
+	return tree.divide(node)
+}
+
+// 上限を超えたノードの子を分け、先頭の組をnodeに残して残りを新しいノードに移す
+func (tree *RTree) divide(node *Node) (siblings Nodes) {
+	siblings = Nodes{node}
+
+	if !node.isOverFlow() {
+		return
+	}
+
+	children := make(Nodes, len(node.Children))
+	copy(children, node.Children)
+
+	node.deleteAllEntry()
+
+	groups := tree.partition(children, node.listIntervalDistance())
+
+	for i, group := range groups {
+		target := node
+
+		if 0 < i {
+			target = tree.NewNode(node.Parent)
+			siblings = append(siblings, target)
+		}
+
+		for _, child := range group {
+			target.AddEntry(child)
+		}
+
+		target.AdjustCoverRectangles()
+	}
+
+	return
+}
+
+// ノードをMaxEntrySize以下の組に分ける
+// 多い場合は中心の広がりが最大の次元で半分に分け、2ノード分以下になったらConfig.Strategyの分割を行う
+func (tree *RTree) partition(nodes Nodes, baseDistances []float64) (groups []Nodes) {
+	if len(nodes) <= tree.cnf.MaxEntrySize {
+		return []Nodes{nodes}
+	}
+
+	var one, another Nodes
+
+	if 2*tree.cnf.MaxEntrySize < len(nodes) {
+		nodes.sortByCenter(nodes.widestDimension())
+		one, another = nodes[:len(nodes)/2], nodes[len(nodes)/2:]
+	} else {
+		one, another = nodes.split(tree.cnf, baseDistances)
+	}
+
+	return append(tree.partition(one, baseDistances), tree.partition(another, baseDistances)...)
+}
+
+// 中心の広がりが最大の次元
+func (nodes Nodes) widestDimension() (widest int) {
+	spread := -1.0
+
+	for dim := range nodes[0].Rectangle {
+		lower, upper := nodes[0].Rectangle[dim].center(), nodes[0].Rectangle[dim].center()
+
+		for _, node := range nodes[1:] {
+			lower = min(lower, node.Rectangle[dim].center())
+			upper = max(upper, node.Rectangle[dim].center())
+		}
+
+		if spread < upper-lower {
+			widest, spread = dim, upper-lower
+		}
+	}
+
+	return
+}
+
+// otherを包含するように短形を広げる
+func (rectangle Rectangle) extend(other Rectangle) {
+	for dim := range rectangle {
+		rectangle[dim].First = min(rectangle[dim].First, other[dim].First)
+		rectangle[dim].Second = max(rectangle[dim].Second, other[dim].Second)
+	}
+}
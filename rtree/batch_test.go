@@ -0,0 +1,140 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// エントリーをリーフエントリーのノードにする
+func takeEntries(tree *rtree.RTree, entries []rtree.Entry) (nodes rtree.Nodes) {
+	for _, e := range entries {
+		nodes = append(nodes, tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First))
+	}
+
+	return
+}
+
+func TestInsertBatch(t *testing.T) {
+	strategies := map[string]rtree.Strategy{
+		"linear":    rtree.Linear,
+		"quadratic": rtree.Quadratic,
+		"rstar":     rtree.RStar,
+	}
+
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			entries := randomEntries(3000, 21)
+			tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 6, Strategy: strategy})
+
+			// 空の木への大きな一括挿入と、既存の木への大小の一括挿入
+			for _, size := range []int{1000, 1, 7, 500, 1492} {
+				assert.NoError(t, tree.InsertBatch(takeEntries(tree, entries[:size])))
+				assert.NoError(t, tree.Validate())
+
+				entries = entries[size:]
+			}
+
+			assert.Empty(t, entries)
+
+			all := randomEntries(3000, 21)
+			r := rand.New(rand.NewSource(22))
+
+			for i := 0; i < 50; i++ {
+				lat, lon := r.Float64()*160-80, r.Float64()*340-170
+				query := rtree.NewBoundingBox(lat, lon, lat+20, lon+20)
+
+				var expected []uint64
+
+				for _, e := range all {
+					if overlaps(e.Rectangle, query) {
+						expected = append(expected, e.ID)
+					}
+				}
+
+				ids, err := tree.Search(query)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, expected, ids)
+			}
+		})
+	}
+
+	t.Run("mixed with add node", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, Strategy: rtree.RStar})
+		entries := randomEntries(400, 23)
+
+		for i := 0; i < len(entries); i += 40 {
+			for _, e := range entries[i : i+20] {
+				assert.NoError(t, tree.AddNode(tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First)))
+			}
+
+			assert.NoError(t, tree.InsertBatch(takeEntries(tree, entries[i+20:i+40])))
+			assert.NoError(t, tree.Validate())
+		}
+
+		assert.NoError(t, tree.Delete(5))
+		assert.NoError(t, tree.Update(25, rect(0, 0, 0, 0)))
+		assert.NoError(t, tree.Validate())
+
+		ids, _ := tree.Search(rtree.NewBoundingBox(-90, -180, 90, 180))
+		assert.Len(t, ids, 399)
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		assert.NoError(t, tree.InsertBatch(rtree.Nodes{tree.TakePlace(1, 0, 0), tree.TakePlace(2, 1, 1)}))
+
+		// 誤りがあれば何も挿入しない
+		err := tree.InsertBatch(rtree.Nodes{tree.TakePlace(3, 2, 2), tree.TakePlace(1, 3, 3)})
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+
+		err = tree.InsertBatch(rtree.Nodes{tree.TakePlace(3, 2, 2), tree.TakePlace(3, 3, 3)})
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+
+		err = tree.InsertBatch(rtree.Nodes{tree.TakePlace(3, 2, 2), tree.TakePoint(4, 1, 2, 3)})
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+		ids, _ := tree.Search(rect(-10, 10, -10, 10))
+		assert.ElementsMatch(t, []uint64{1, 2}, ids)
+
+		assert.NoError(t, tree.InsertBatch(nil))
+		assert.NoError(t, tree.Validate())
+	})
+}
+
+func BenchmarkInsertBatch(b *testing.B) {
+	entries := randomEntries(50000, 24)
+	strategies := map[string]rtree.Strategy{
+		"linear": rtree.Linear,
+		"rstar":  rtree.RStar,
+	}
+
+	for name, strategy := range strategies {
+		b.Run("add node "+name, func(bSub *testing.B) {
+			for i := 0; i < bSub.N; i++ {
+				tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 16, Strategy: strategy})
+
+				for _, node := range takeEntries(tree, entries) {
+					_ = tree.AddNode(node)
+				}
+			}
+		})
+
+		// 取り込みの単位毎にまとめて挿入する
+		for _, size := range []int{100, 1000, 10000} {
+			b.Run("insert batch "+name+" "+strconv.Itoa(size), func(bSub *testing.B) {
+				for i := 0; i < bSub.N; i++ {
+					tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 16, Strategy: strategy})
+					nodes := takeEntries(tree, entries)
+
+					for start := 0; start < len(nodes); start += size {
+						_ = tree.InsertBatch(nodes[start:min(start+size, len(nodes))])
+					}
+				}
+			})
+		}
+	}
+}
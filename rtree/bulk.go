@@ -0,0 +1,294 @@
+package rtree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+type (
+	// Entry 一括構築するリーフエントリー
+	Entry struct {
+		ID        uint64
+		Rectangle Rectangle
+	}
+
+	// Packing 一括構築時のエントリーの詰め込み方式
+	Packing int
+)
+
+const (
+	// STR Sort-Tile-Recursive. 次元毎に整列してタイル状に詰め込む
+	STR Packing = iota
+	// Hilbert 中心点のヒルベルト曲線上の順序で詰め込む
+	Hilbert
+)
+
+const hilbertOrder = 16
+
+// BulkLoad エントリーを下から詰め込んで木を構築する. 詰め込み方式はConfig.Packingに従う
+// MaxEntrySizeが2未満、またはMinEntrySizeがMaxEntrySizeの半分を超える場合は ErrInvalidConfig を返す
+func BulkLoad(cnf *Config, entries []Entry) (tree *RTree, err error) {
+	// 上の階層でノード数が減らない設定では構築が終わらない
+	if cnf.MaxEntrySize < 2 || cnf.MaxEntrySize/2 < cnf.MinEntrySize {
+		return nil, ErrInvalidConfig
+	}
+
+	tree = NewRTree(cnf)
+
+	level := make(Nodes, 0, len(entries))
+
+	for i := range entries {
+		if !tree.validEntry(entries[i].Rectangle) {
+			return nil, ErrInvalidRectangle
+		}
+
+		if _, ok := tree.entries[entries[i].ID]; ok {
+			return nil, ErrDuplicateID
+		}
+
+		// 呼び出し元のスライスを書き換えられても索引とずれないよう複製する
+		id := entries[i].ID
+
+		node := tree.NewNode(nil)
+		node.Rectangle = entries[i].Rectangle
+		node.DataID = &id
+
+		tree.entries[id] = node
+		level = append(level, node)
+	}
+
+	if len(level) == 0 {
+		return
+	}
+
+	// 1ノードに収まるまで上の階層を作る
+	for {
+		if len(level) <= cnf.MaxEntrySize {
+			for _, node := range level {
+				tree.Root.AddEntry(node)
+			}
+
+			tree.Root.AdjustCoverRectangles()
+
+			return
+		}
+
+		level = tree.pack(level)
+	}
+}
+
+// ひとつ上の階層のノードへ詰め込む
+func (tree *RTree) pack(nodes Nodes) (parents Nodes) {
+	var groups []Nodes
+
+	switch tree.cnf.Packing {
+	case Hilbert:
+		groups = tree.chunk(nodes.sortByHilbert())
+	default:
+		groups = tree.tile(nodes, 0)
+	}
+
+	groups = tree.balance(groups)
+
+	parents = make(Nodes, 0, len(groups))
+
+	for _, group := range groups {
+		parent := tree.NewNode(nil)
+
+		for _, node := range group {
+			parent.AddEntry(node)
+		}
+
+		parent.AdjustCoverRectangles()
+		parents = append(parents, parent)
+	}
+
+	return
+}
+
+// STR: 次元dimで整列してスライスに分け、残りの次元で再帰的にタイル化する
+func (tree *RTree) tile(nodes Nodes, dim int) []Nodes {
+	nodes.sortByCenter(dim)
+
+	dimension := tree.cnf.dimension()
+
+	if dim == dimension-1 {
+		return tree.chunk(nodes)
+	}
+
+	pageCount := math.Ceil(float64(len(nodes)) / float64(tree.cnf.MaxEntrySize))
+	sliceCount := math.Ceil(math.Pow(pageCount, 1/float64(dimension-dim)))
+	sliceSize := int(math.Ceil(pageCount/sliceCount)) * tree.cnf.MaxEntrySize
+
+	var groups []Nodes
+
+	for start := 0; start < len(nodes); start += sliceSize {
+		end := start + sliceSize
+		if len(nodes) < end {
+			end = len(nodes)
+		}
+
+		groups = append(groups, tree.tile(nodes[start:end], dim+1)...)
+	}
+
+	return groups
+}
+
+// 順序を保ったままMaxEntrySize毎に区切る
+func (tree *RTree) chunk(nodes Nodes) (groups []Nodes) {
+	size := tree.cnf.MaxEntrySize
+
+	for start := 0; start < len(nodes); start += size {
+		end := start + size
+		if len(nodes) < end {
+			end = len(nodes)
+		}
+
+		groups = append(groups, nodes[start:end])
+	}
+
+	return
+}
+
+// 下限を下回る組を隣の組と合わせる. MaxEntrySizeに収まらなければ均等に分け合う
+// STRではスライス毎に区切るため、末尾の組はスライスを跨いで隣の組と合わせる
+func (tree *RTree) balance(groups []Nodes) []Nodes {
+	minSize := tree.cnf.minEntrySize()
+
+	for i := 0; i < len(groups) && 1 < len(groups); {
+		if minSize <= len(groups[i]) {
+			i++
+			continue
+		}
+
+		// 前の組と合わせる. 先頭の組は次の組と合わせる
+		first := i - 1
+		if i == 0 {
+			first = 0
+		}
+		merged := slices.Concat(groups[first], groups[first+1])
+
+		if len(merged) <= tree.cnf.MaxEntrySize {
+			groups = slices.Replace(groups, first, first+2, merged)
+		} else {
+			// MaxEntrySize は MinEntrySize の2倍以上なので、両方とも下限を満たす
+			half := len(merged) / 2
+			groups = slices.Replace(groups, first, first+2, merged[:half], merged[half:])
+		}
+
+		i = first
+	}
+
+	return groups
+}
+
+func (nodes Nodes) sortByCenter(dim int) {
+	slices.SortFunc(nodes, func(a, b *Node) int {
+		return cmp.Compare(a.Rectangle[dim].center(), b.Rectangle[dim].center())
+	})
+}
+
+// 中心点のヒルベルト値の順に並べる
+func (nodes Nodes) sortByHilbert() Nodes {
+	dimension := len(nodes[0].Rectangle)
+	bounds := zeroRectangle(dimension)
+
+	for dim := range bounds {
+		bounds[dim].First = math.MaxFloat64
+		bounds[dim].Second = -math.MaxFloat64
+
+		for _, node := range nodes {
+			bounds[dim].First = min(bounds[dim].First, node.Rectangle[dim].center())
+			bounds[dim].Second = max(bounds[dim].Second, node.Rectangle[dim].center())
+		}
+	}
+
+	// 64bitに収まるよう次元毎のビット数を決める
+	order := uint(hilbertOrder)
+	if 64/uint(dimension) < order {
+		order = 64 / uint(dimension)
+	}
+
+	keys := make(map[*Node]uint64, len(nodes))
+	coords := make([]uint64, dimension)
+
+	for _, node := range nodes {
+		for dim := range coords {
+			coords[dim] = bounds[dim].scale(node.Rectangle[dim].center(), order)
+		}
+
+		keys[node] = hilbertIndex(coords, order)
+	}
+
+	slices.SortFunc(nodes, func(a, b *Node) int {
+		return cmp.Compare(keys[a], keys[b])
+	})
+
+	return nodes
+}
+
+// 区間の中心
+func (internal Inteval) center() float64 {
+	return internal.First/2 + internal.Second/2
+}
+
+// 区間内の値を[0, 2^order)の整数へ写像する
+func (internal Inteval) scale(value float64, order uint) uint64 {
+	width := internal.Second - internal.First
+	if width <= 0 || math.IsInf(width, 0) {
+		return 0
+	}
+
+	cells := float64(uint64(1) << order)
+
+	return uint64(math.Min(cells-1, (value-internal.First)/width*cells))
+}
+
+// 多次元のヒルベルト曲線上の位置. coordsは書き換えられる
+// Skilling, "Programming the Hilbert curve" (2004)
+func hilbertIndex(coords []uint64, order uint) (d uint64) {
+	n := len(coords)
+	top := uint64(1) << (order - 1)
+
+	// 座標を転置形式のヒルベルト値へ変換する
+	for q := top; 1 < q; q >>= 1 {
+		p := q - 1
+
+		for i := range coords {
+			if coords[i]&q != 0 {
+				coords[0] ^= p
+			} else {
+				t := (coords[0] ^ coords[i]) & p
+				coords[0] ^= t
+				coords[i] ^= t
+			}
+		}
+	}
+
+	// グレイ符号化
+	for i := 1; i < n; i++ {
+		coords[i] ^= coords[i-1]
+	}
+
+	var t uint64
+
+	for q := top; 1 < q; q >>= 1 {
+		if coords[n-1]&q != 0 {
+			t ^= q - 1
+		}
+	}
+
+	for i := range coords {
+		coords[i] ^= t
+	}
+
+	// 上位ビットから次元順に並べる
+	for b := int(order) - 1; 0 <= b; b-- {
+		for i := range coords {
+			d = d<<1 | (coords[i]>>uint(b))&1
+		}
+	}
+
+	return
+}
@@ -0,0 +1,161 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func randomEntries(n int, seed int64) []rtree.Entry {
+	r := rand.New(rand.NewSource(seed))
+	entries := make([]rtree.Entry, n)
+
+	for i := range entries {
+		lat, lon := r.Float64()*180-90, r.Float64()*360-180
+		entries[i] = rtree.Entry{ID: uint64(i), Rectangle: rtree.NewBoundingBox(lat, lon, lat, lon)}
+	}
+
+	return entries
+}
+
+// dimension次元の点のエントリー
+func randomEntriesIn(dimension, n int, seed int64) []rtree.Entry {
+	r := rand.New(rand.NewSource(seed))
+	entries := make([]rtree.Entry, n)
+
+	for i := range entries {
+		rectangle := make(rtree.Rectangle, dimension)
+		for dim := range rectangle {
+			v := r.Float64() * 100
+			rectangle[dim] = &rtree.Inteval{First: v, Second: v}
+		}
+
+		entries[i] = rtree.Entry{ID: uint64(i), Rectangle: rectangle}
+	}
+
+	return entries
+}
+
+// 全リーフの深さ
+func leafDepths(node *rtree.Node, depth int) (depths []int) {
+	for _, child := range node.Children {
+		if child.DataID != nil {
+			return []int{depth}
+		}
+
+		depths = append(depths, leafDepths(child, depth+1)...)
+	}
+
+	return
+}
+
+func TestBulkLoad(t *testing.T) {
+	for _, packing := range []rtree.Packing{rtree.STR, rtree.Hilbert} {
+		t.Run("search same as brute force", func(t *testing.T) {
+			entries := randomEntries(1000, 1)
+
+			tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8, Packing: packing}, entries)
+			assert.NoError(t, err)
+
+			query := rtree.NewBoundingBox(-30, -60, 30, 60)
+
+			var want []uint64
+
+			for _, e := range entries {
+				if query[0].First <= e.Rectangle[0].First && e.Rectangle[0].First <= query[0].Second &&
+					query[1].First <= e.Rectangle[1].First && e.Rectangle[1].First <= query[1].Second {
+					want = append(want, e.ID)
+				}
+			}
+
+			ids, err := tree.Search(query)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, want, ids)
+		})
+
+		t.Run("packed and balanced", func(t *testing.T) {
+			tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8, Packing: packing}, randomEntries(1000, 2))
+			assert.NoError(t, err)
+
+			depths := leafDepths(tree.Root, 0)
+			for _, d := range depths {
+				assert.Equal(t, depths[0], d)
+			}
+
+			// 1000 / 8 = 125 リーフ, 16ノード, 2ノード, ルート
+			assert.Equal(t, 3, depths[0])
+			assert.Len(t, depths, 125)
+
+			// 挿入・削除も行える
+			assert.NoError(t, tree.AddNode(tree.TakePlace(1000, 0, 0)))
+			assert.NoError(t, tree.Delete(0))
+			assert.Len(t, collectIDs(tree.Root), 1000)
+		})
+	}
+
+	t.Run("small and empty", func(t *testing.T) {
+		tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8}, randomEntries(3, 3))
+		assert.NoError(t, err)
+		assert.Len(t, tree.Root.Children, 3)
+
+		tree, err = rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8}, nil)
+		assert.NoError(t, err)
+		assert.Empty(t, tree.Root.Children)
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		entries := randomEntries(3, 4)
+		entries[2].ID = 0
+
+		_, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8}, entries)
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+	})
+
+	t.Run("invalid config", func(t *testing.T) {
+		for _, cnf := range []*rtree.Config{{MaxEntrySize: 0}, {MaxEntrySize: 1}, {MaxEntrySize: 6, MinEntrySize: 4}} {
+			_, err := rtree.BulkLoad(cnf, randomEntries(10, 6))
+			assert.ErrorIs(t, err, rtree.ErrInvalidConfig)
+		}
+	})
+
+	t.Run("entries are copied", func(t *testing.T) {
+		entries := randomEntries(3, 7)
+
+		tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8}, entries)
+		assert.NoError(t, err)
+
+		entries[0].ID = 99
+
+		assert.NotContains(t, collectIDs(tree.Root), uint64(99))
+		assert.NoError(t, tree.Delete(0))
+	})
+}
+
+func BenchmarkBulkLoad(b *testing.B) {
+	entries := randomEntries(100000, 5)
+	cnf := &rtree.Config{MaxEntrySize: 16}
+
+	b.Run("add node", func(bSub *testing.B) {
+		for i := 0; i < bSub.N; i++ {
+			tree := rtree.NewRTree(cnf)
+
+			for _, e := range entries {
+				_ = tree.AddNode(tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First))
+			}
+		}
+	})
+
+	b.Run("bulk load STR", func(bSub *testing.B) {
+		for i := 0; i < bSub.N; i++ {
+			_, _ = rtree.BulkLoad(&rtree.Config{MaxEntrySize: 16, Packing: rtree.STR}, entries)
+		}
+	})
+
+	b.Run("bulk load Hilbert", func(bSub *testing.B) {
+		for i := 0; i < bSub.N; i++ {
+			_, _ = rtree.BulkLoad(&rtree.Config{MaxEntrySize: 16, Packing: rtree.Hilbert}, entries)
+		}
+	})
+}
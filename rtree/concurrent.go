@@ -0,0 +1,127 @@
+package rtree
+
+import (
+	"io"
+	"iter"
+	"sync"
+)
+
+// SyncRTree 複数のゴルーチンから同時に使えるRTree
+// 探索は読み取りロック、更新は書き込みロックで行うため、探索同士は並行して実行される
+type SyncRTree struct {
+	mu   sync.RWMutex
+	tree *RTree
+}
+
+func NewSyncRTree(cnf *Config) *SyncRTree {
+	return &SyncRTree{tree: NewRTree(cnf)}
+}
+
+// Synchronized 既存の木を包む. 以降は元の木を直接操作しないこと
+func Synchronized(tree *RTree) *SyncRTree {
+	return &SyncRTree{tree: tree}
+}
+
+// TakePlace RTree.TakePlace と同じ. 木は変更しない
+func (s *SyncRTree) TakePlace(id uint64, lat, lon float64) *Node {
+	return s.tree.TakePlace(id, lat, lon)
+}
+
+// TakePoint RTree.TakePoint と同じ. 木は変更しない
+func (s *SyncRTree) TakePoint(id uint64, point ...float64) *Node {
+	return s.tree.TakePoint(id, point...)
+}
+
+func (s *SyncRTree) AddNode(src *Node) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tree.AddNode(src)
+}
+
+func (s *SyncRTree) InsertBatch(entries Nodes) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tree.InsertBatch(entries)
+}
+
+func (s *SyncRTree) Delete(id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tree.Delete(id)
+}
+
+func (s *SyncRTree) Update(id uint64, rectangle Rectangle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.tree.Update(id, rectangle)
+}
+
+func (s *SyncRTree) Search(rectangle Rectangle, opts ...SearchOption) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.Search(rectangle, opts...)
+}
+
+// SearchFunc 読み取りロックを保持したままfnを呼び出す. fnから更新系のメソッドを呼ぶとデッドロックする
+func (s *SyncRTree) SearchFunc(rectangle Rectangle, fn func(id uint64) bool, opts ...SearchOption) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.SearchFunc(rectangle, fn, opts...)
+}
+
+// All 列挙の間は読み取りロックを保持する
+func (s *SyncRTree) All(rectangle Rectangle, opts ...SearchOption) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		_ = s.SearchFunc(rectangle, yield, opts...)
+	}
+}
+
+func (s *SyncRTree) Nearest(lat, lon float64, k int, opts ...NearestOption) ([]Neighbor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.Nearest(lat, lon, k, opts...)
+}
+
+func (s *SyncRTree) NearestPoint(point []float64, k int, opts ...NearestOption) ([]Neighbor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.NearestPoint(point, k, opts...)
+}
+
+func (s *SyncRTree) WithinRadius(lat, lon, meters float64) ([]Neighbor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.WithinRadius(lat, lon, meters)
+}
+
+func (s *SyncRTree) Save(w io.Writer) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.Save(w)
+}
+
+// Read 読み取りロックを保持したままfnを呼び出す. fnの中で木を変更してはならない
+func (s *SyncRTree) Read(fn func(tree *RTree)) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	fn(s.tree)
+}
+
+// Write 書き込みロックを保持したままfnを呼び出す. 複数の更新をまとめて行う場合に使う
+func (s *SyncRTree) Write(fn func(tree *RTree) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return fn(s.tree)
+}
@@ -0,0 +1,90 @@
+package rtree_test
+
+import (
+	"bytes"
+	"rtree"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncRTree(t *testing.T) {
+	tree := rtree.NewSyncRTree(&rtree.Config{MaxEntrySize: 6, Strategy: rtree.RStar})
+	entries := randomEntries(2000, 13)
+
+	var wg sync.WaitGroup
+
+	// 書き込み
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+
+		go func(w int) {
+			defer wg.Done()
+
+			for i := w; i < len(entries); i += 4 {
+				e := entries[i]
+				assert.NoError(t, tree.AddNode(tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First)))
+
+				// 一部は削除と移動
+				switch i % 10 {
+				case 0:
+					assert.NoError(t, tree.Delete(e.ID))
+				case 1:
+					assert.NoError(t, tree.Update(e.ID, rtree.NewBoundingBox(0, 0, 0, 0)))
+				}
+			}
+		}(w)
+	}
+
+	// 読み込み
+	for r := 0; r < 4; r++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < 200; i++ {
+				_, err := tree.Search(rtree.NewBoundingBox(-45, -90, 45, 90))
+				assert.NoError(t, err)
+
+				_, err = tree.Nearest(0, 0, 5)
+				assert.NoError(t, err)
+
+				for range tree.All(rtree.NewBoundingBox(-10, -10, 10, 10)) {
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	ids, err := tree.Search(rtree.NewBoundingBox(-90, -180, 90, 180))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 1800)
+
+	moved, err := tree.Search(rtree.NewBoundingBox(0, 0, 0, 0))
+	assert.NoError(t, err)
+	assert.Len(t, moved, 200)
+
+	tree.Read(func(tree *rtree.RTree) {
+		assert.Len(t, collectIDs(tree.Root), 1800)
+	})
+
+	assert.NoError(t, tree.Write(func(tree *rtree.RTree) error {
+		for _, id := range moved {
+			if err := tree.Delete(id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.Save(&buf))
+
+	loaded, err := rtree.Load(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, collectIDs(loaded.Root), 1600)
+}
@@ -0,0 +1,100 @@
+package rtree
+
+// Delete DataIDのリーフエントリーを削除する
+func (tree *RTree) Delete(id uint64) (err error) {
+	entry, ok := tree.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	tree.remove(entry)
+	delete(tree.entries, id)
+
+	return
+}
+
+// Update DataIDのリーフエントリーを新しい短形へ移動する. 短形が不正ならエントリーは元のまま
+// 挿入は失敗しないため、検査を通れば必ず移動する
+// 形状を持つエントリーは短形のエントリーになる. 形状ごと移動する場合は UpdateGeometry を使う
+func (tree *RTree) Update(id uint64, rectangle Rectangle) (err error) {
+	if !tree.validEntry(rectangle) {
+		return ErrInvalidRectangle
+	}
+
+	entry, ok := tree.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	tree.remove(entry)
+
+	entry.Rectangle = rectangle
+	entry.Geometry = nil
+
+	tree.insert(entry)
+
+	return
+}
+
+// リーフエントリーを木から外し、木を縮退させる
+func (tree *RTree) remove(entry *Node) {
+	leaf := entry.Parent
+	leaf.Children.delete(entry)
+	entry.Parent = nil
+
+	orphans := leaf.condense()
+
+	tree.collapseRoot()
+
+	// 下限を下回って外れたノード配下のエントリーを再挿入する
+	for _, orphan := range orphans {
+		orphan.Parent = nil
+		tree.insert(orphan)
+	}
+}
+
+// CondenseTree: 下限を下回ったノードを親から外し、祖先の短形を縮小する
+func (node *Node) condense() (orphans Nodes) {
+	for n := node; !n.isRoot(); {
+		parent := n.Parent
+
+		if n.isUnderFlow() {
+			parent.Children.delete(n)
+			orphans = append(orphans, n.listDataNodes()...)
+		} else {
+			n.AdjustCoverRectangles()
+		}
+
+		n = parent
+	}
+
+	return
+}
+
+// 子が1つだけのルートを畳み込む
+func (tree *RTree) collapseRoot() {
+	for !tree.Root.isLeaf() && len(tree.Root.Children) == 1 {
+		tree.Root = tree.Root.Children[0]
+		tree.Root.Parent = nil
+	}
+
+	if len(tree.Root.Children) == 0 {
+		tree.Root.Rectangle = maxRectangle(tree.cnf.dimension())
+		return
+	}
+
+	tree.Root.AdjustCoverRectangles()
+}
+
+// 配下の全リーフエントリー
+func (node *Node) listDataNodes() (entries Nodes) {
+	if node.DataID != nil {
+		return Nodes{node}
+	}
+
+	for _, child := range node.Children {
+		entries = append(entries, child.listDataNodes()...)
+	}
+
+	return
+}
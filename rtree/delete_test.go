@@ -0,0 +1,146 @@
+package rtree_test
+
+import (
+	"math"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// 配下の全DataID
+func collectIDs(node *rtree.Node) (ids []uint64) {
+	if node.DataID != nil {
+		return []uint64{*node.DataID}
+	}
+
+	for _, child := range node.Children {
+		ids = append(ids, collectIDs(child)...)
+	}
+
+	return
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("delete from root", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+		_ = tree.AddNode(tree.TakePlace(1, 1, 1))
+		_ = tree.AddNode(tree.TakePlace(2, 5, 8))
+
+		assert.NoError(t, tree.Delete(1))
+		assert.ElementsMatch(t, []uint64{2}, collectIDs(tree.Root))
+
+		// 短形が縮小している
+		assert.EqualValues(t, 5, tree.Root.Rectangle[0].First)
+		assert.EqualValues(t, 8, tree.Root.Rectangle[1].First)
+
+		assert.ErrorIs(t, tree.Delete(1), rtree.ErrNotFound)
+	})
+
+	t.Run("delete all", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		for i := uint64(1); i <= 5; i++ {
+			_ = tree.AddNode(tree.TakePlace(i, float64(i), float64(i)))
+		}
+
+		for i := uint64(1); i <= 5; i++ {
+			assert.NoError(t, tree.Delete(i))
+		}
+
+		assert.Empty(t, tree.Root.Children)
+		assert.Nil(t, tree.Root.Parent)
+
+		// 削除後も再挿入できる
+		assert.NoError(t, tree.AddNode(tree.TakePlace(1, 1, 1)))
+		assert.ElementsMatch(t, []uint64{1}, collectIDs(tree.Root))
+	})
+
+	t.Run("underflow and collapse root", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		place := []*rtree.Node{
+			tree.TakePlace(1, 1, 1),
+			tree.TakePlace(2, 1, 2),
+			tree.TakePlace(3, 2, 3),
+		}
+
+		for _, p := range place {
+			_ = tree.AddNode(p)
+		}
+
+		// root
+		// node node
+		// 1 2  3
+		assert.Equal(t, 2, len(tree.Root.Children))
+
+		// 3を含むノードが下限を下回り、ルートが畳み込まれる
+		assert.NoError(t, tree.Delete(3))
+
+		assert.Nil(t, tree.Root.Parent)
+		assert.ElementsMatch(t, rtree.Nodes{place[0], place[1]}, tree.Root.Children)
+		assert.EqualValues(t, 1, tree.Root.Rectangle[0].First)
+		assert.EqualValues(t, 1, tree.Root.Rectangle[0].Second)
+		assert.EqualValues(t, 1, tree.Root.Rectangle[1].First)
+		assert.EqualValues(t, 2, tree.Root.Rectangle[1].Second)
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	t.Run("move entry", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+		place := tree.TakePlace(1, 1, 1)
+		_ = tree.AddNode(place)
+		_ = tree.AddNode(tree.TakePlace(2, 2, 2))
+
+		assert.NoError(t, tree.Update(1, rtree.Rectangle{&rtree.Inteval{First: 10, Second: 10}, &rtree.Inteval{First: 20, Second: 20}}))
+
+		assert.ElementsMatch(t, []uint64{1, 2}, collectIDs(tree.Root))
+		assert.EqualValues(t, 10, place.Rectangle[0].First)
+		assert.EqualValues(t, 2, tree.Root.Rectangle[0].First)
+		assert.EqualValues(t, 10, tree.Root.Rectangle[0].Second)
+		assert.EqualValues(t, 20, tree.Root.Rectangle[1].Second)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+		err := tree.Update(1, rtree.Rectangle{&rtree.Inteval{}, &rtree.Inteval{}})
+		assert.ErrorIs(t, err, rtree.ErrNotFound)
+	})
+
+	t.Run("invalid rectangle keeps entry", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+		for i := uint64(1); i <= 10; i++ {
+			_ = tree.AddNode(tree.TakePlace(i, float64(i), float64(i)))
+		}
+
+		for _, r := range []rtree.Rectangle{
+			{&rtree.Inteval{First: 1, Second: 1}},
+			{&rtree.Inteval{First: 1, Second: 1}, nil},
+			{&rtree.Inteval{First: math.NaN(), Second: 1}, &rtree.Inteval{First: 1, Second: 1}},
+			{&rtree.Inteval{First: 1, Second: 1}, &rtree.Inteval{First: 170, Second: -170}},
+		} {
+			assert.ErrorIs(t, tree.Update(3, r), rtree.ErrInvalidRectangle)
+		}
+
+		// 経度180度を跨ぐ区間は探索短形でのみ使える
+		assert.ErrorIs(t, tree.AddNode(tree.TakeRectangle(11, rtree.NewBoundingBox(0, 170, 1, -170))), rtree.ErrInvalidRectangle)
+
+		ids, _ := tree.Search(rtree.NewBoundingBox(3, 3, 3, 3))
+		assert.Equal(t, []uint64{3}, ids)
+		assert.NoError(t, tree.Validate())
+
+		assert.NoError(t, tree.Delete(3))
+	})
+}
+
+func TestAddNodeDuplicate(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+	assert.NoError(t, tree.AddNode(tree.TakePlace(1, 1, 1)))
+	assert.ErrorIs(t, tree.AddNode(tree.TakePlace(1, 2, 2)), rtree.ErrDuplicateID)
+}
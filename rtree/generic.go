@@ -0,0 +1,146 @@
+package rtree
+
+import "iter"
+
+type (
+	// Tree 任意の値をDataID毎に保持し、探索結果として値を直接返す木
+	// IDのみを扱う場合は RTree を使う
+	Tree[T any] struct {
+		tree   *RTree
+		values map[uint64]T
+	}
+
+	// Found 近傍探索の結果
+	Found[T any] struct {
+		ID       uint64
+		Value    T
+		Distance float64
+	}
+)
+
+func NewTree[T any](cnf *Config) *Tree[T] {
+	return &Tree[T]{tree: NewRTree(cnf), values: make(map[uint64]T)}
+}
+
+// RTree 短形を保持している元の木. 値は含まないため、Save/Load では値は保存されない
+// 元の木を直接変更した場合、値は追従しない
+func (t *Tree[T]) RTree() *RTree {
+	return t.tree
+}
+
+// Insert 短形と値を挿入する
+func (t *Tree[T]) Insert(id uint64, rectangle Rectangle, value T) error {
+	node := t.tree.NewNode(nil)
+	node.Rectangle = rectangle
+	node.DataID = &id
+
+	return t.add(node, value)
+}
+
+// InsertGeometry 形状と値を挿入する
+func (t *Tree[T]) InsertGeometry(id uint64, geometry Geometry, value T) error {
+	return t.add(t.tree.TakeGeometry(id, geometry), value)
+}
+
+func (t *Tree[T]) add(node *Node, value T) (err error) {
+	if err = t.tree.AddNode(node); err != nil {
+		return
+	}
+
+	t.values[*node.DataID] = value
+
+	return
+}
+
+func (t *Tree[T]) Delete(id uint64) (err error) {
+	if err = t.tree.Delete(id); err != nil {
+		return
+	}
+
+	delete(t.values, id)
+
+	return
+}
+
+// Update 短形と値を更新する. 失敗した場合は短形と値のどちらも変更しない
+func (t *Tree[T]) Update(id uint64, rectangle Rectangle, value T) (err error) {
+	if err = t.tree.Update(id, rectangle); err != nil {
+		return
+	}
+
+	t.values[id] = value
+
+	return
+}
+
+// Get IDの値
+func (t *Tree[T]) Get(id uint64) (value T, ok bool) {
+	if _, ok = t.tree.entries[id]; !ok {
+		return
+	}
+
+	return t.values[id], true
+}
+
+// Search 探索短形に該当する全ての値を返却する
+func (t *Tree[T]) Search(rectangle Rectangle, opts ...SearchOption) (results []T, err error) {
+	err = t.tree.searchEntries(rectangle, opts, func(entry *Node) bool {
+		results = append(results, t.valueOf(entry))
+		return true
+	})
+
+	return
+}
+
+// SearchFunc 探索短形に該当するエントリー毎にfnを呼び出す. fnがfalseを返したら打ち切る
+func (t *Tree[T]) SearchFunc(rectangle Rectangle, fn func(id uint64, value T) bool, opts ...SearchOption) error {
+	return t.tree.searchEntries(rectangle, opts, func(entry *Node) bool {
+		return fn(*entry.DataID, t.valueOf(entry))
+	})
+}
+
+// All 探索短形に該当するIDと値を列挙する
+func (t *Tree[T]) All(rectangle Rectangle, opts ...SearchOption) iter.Seq2[uint64, T] {
+	return func(yield func(uint64, T) bool) {
+		_ = t.SearchFunc(rectangle, yield, opts...)
+	}
+}
+
+// Nearest RTree.Nearest の値を返す版
+func (t *Tree[T]) Nearest(lat, lon float64, k int, opts ...NearestOption) ([]Found[T], error) {
+	if t.tree.cnf.dimension() < defaultDimension {
+		return nil, ErrInvalidRectangle
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	metric := t.tree.cnf.Metric
+
+	return t.found(t.tree.nearest(k, newNearestOption(opts), func(rectangle Rectangle) float64 {
+		return metric.minDistance(rectangle, lat, lon)
+	})), nil
+}
+
+// WithinRadius RTree.WithinRadius の値を返す版
+func (t *Tree[T]) WithinRadius(lat, lon, meters float64) ([]Found[T], error) {
+	if t.tree.cnf.dimension() < defaultDimension {
+		return nil, ErrInvalidRectangle
+	}
+
+	return t.found(t.tree.withinRadius(lat, lon, meters)), nil
+}
+
+func (t *Tree[T]) found(items []nearestItem) (results []Found[T]) {
+	for _, item := range items {
+		results = append(results, Found[T]{ID: *item.node.DataID, Value: t.valueOf(item.node), Distance: item.distance})
+	}
+
+	return
+}
+
+// エントリーの値. 値を持たない(元の木へ直接挿入した等)場合はゼロ値
+func (t *Tree[T]) valueOf(entry *Node) T {
+	return t.values[*entry.DataID]
+}
@@ -0,0 +1,88 @@
+package rtree_test
+
+import (
+	"rtree"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type store struct {
+	Name     string
+	Lat, Lon float64
+}
+
+func TestTree(t *testing.T) {
+	tree := rtree.NewTree[*store](&rtree.Config{MaxEntrySize: 2, Metric: rtree.Haversine})
+
+	stores := []*store{
+		{Name: "東京", Lat: 35.681236, Lon: 139.767125},
+		{Name: "新宿", Lat: 35.690921, Lon: 139.700258},
+		{Name: "品川", Lat: 35.628471, Lon: 139.738760},
+		{Name: "新大阪", Lat: 34.733165, Lon: 135.500214},
+	}
+
+	for i, s := range stores {
+		assert.NoError(t, tree.Insert(uint64(i), rtree.NewBoundingBox(s.Lat, s.Lon, s.Lat, s.Lon), s))
+	}
+
+	t.Run("search", func(t *testing.T) {
+		values, err := tree.Search(rtree.NewBoundingBox(35, 139, 36, 140))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, stores[:3], values)
+
+		var names []string
+		for _, s := range tree.All(rtree.NewBoundingBox(34, 135, 35, 136)) {
+			names = append(names, s.Name)
+		}
+
+		assert.Equal(t, []string{"新大阪"}, names)
+	})
+
+	t.Run("nearest", func(t *testing.T) {
+		results, err := tree.Nearest(35.681236, 139.767125, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, "東京", results[0].Value.Name)
+		assert.Equal(t, "新宿", results[1].Value.Name)
+		assert.EqualValues(t, 1, results[1].ID)
+
+		results, err = tree.WithinRadius(35.681236, 139.767125, 10000)
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+	})
+
+	t.Run("get delete update", func(t *testing.T) {
+		s, ok := tree.Get(3)
+		assert.True(t, ok)
+		assert.Same(t, stores[3], s)
+
+		moved := &store{Name: "新大阪 (移転)", Lat: 35.6, Lon: 139.7}
+		assert.NoError(t, tree.Update(3, rtree.NewBoundingBox(35.6, 139.7, 35.6, 139.7), moved))
+		assert.ErrorIs(t, tree.Update(3, rtree.Rectangle{}, stores[3]), rtree.ErrInvalidRectangle)
+		assert.NoError(t, tree.Delete(0))
+
+		_, ok = tree.Get(0)
+		assert.False(t, ok)
+
+		values, err := tree.Search(rtree.NewBoundingBox(35, 139, 36, 140))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []*store{stores[1], stores[2], moved}, values)
+	})
+
+	t.Run("value type", func(t *testing.T) {
+		tree := rtree.NewTree[string](&rtree.Config{MaxEntrySize: 4})
+
+		_ = tree.Insert(1, rtree.NewBoundingBox(0, 0, 1, 1), "a")
+		_ = tree.Insert(2, rtree.NewBoundingBox(2, 2, 3, 3), "b")
+
+		values, err := tree.Search(rtree.NewBoundingBox(0, 0, 3, 3))
+		assert.NoError(t, err)
+		slices.Sort(values)
+		assert.Equal(t, []string{"a", "b"}, values)
+
+		ids, err := tree.RTree().Search(rtree.NewBoundingBox(0, 0, 3, 3))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2}, ids)
+	})
+}
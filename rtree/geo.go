@@ -0,0 +1,269 @@
+package rtree
+
+import (
+	"math"
+	"slices"
+	"sort"
+)
+
+// Metric 地点間の距離の測り方
+type Metric int
+
+const (
+	// Euclidean 緯度経度をそのまま平面座標とみなす (単位: 度)
+	Euclidean Metric = iota
+	// Haversine 球面上の大円距離 (単位: m)
+	Haversine
+	// Vincenty 回転楕円体(WGS84)上の測地線距離 (単位: m)
+	Vincenty
+)
+
+const (
+	earthRadius = 6371008.8 // 平均半径 (m)
+
+	wgs84A = 6378137.0
+	wgs84F = 1 / 298.257223563
+	wgs84B = wgs84A * (1 - wgs84F)
+
+	// 楕円体上の距離は球面距離との差が0.6%未満のため、枝刈りの下限には球面距離を縮めて用いる
+	vincentyLowerBound = 0.99
+
+	vincentyMaxIteration = 200
+)
+
+// Distance 2地点間の距離
+func (metric Metric) Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	switch metric {
+	case Haversine:
+		return haversine(lat1, lon1, lat2, lon2)
+	case Vincenty:
+		return vincenty(lat1, lon1, lat2, lon2)
+	default:
+		return math.Hypot(lat1-lat2, lon1-lon2)
+	}
+}
+
+// 地点から短形までの最小距離. Vincentyで短形が点でない場合は下限値を返す
+func (metric Metric) minDistance(rectangle Rectangle, lat, lon float64) float64 {
+	switch metric {
+	case Haversine:
+		return sphericalMinDistance(rectangle, lat, lon)
+	case Vincenty:
+		if rectangle[0].First == rectangle[0].Second && rectangle[1].First == rectangle[1].Second {
+			return vincenty(lat, lon, rectangle[0].First, rectangle[1].First)
+		}
+
+		return sphericalMinDistance(rectangle, lat, lon) * vincentyLowerBound
+	default:
+		return rectangle.minDistance(Rectangle{
+			&Inteval{First: lat, Second: lat},
+			&Inteval{First: lon, Second: lon},
+		})
+	}
+}
+
+// WithinRadius 地点から半径meters以内のエントリーを距離の昇順に返却する
+// 円を包む緯度経度の短形(経度180度を跨ぐ場合も含む)で枝刈りし、測地線距離で絞り込む
+func (tree *RTree) WithinRadius(lat, lon, meters float64) (results []Neighbor, err error) {
+	if tree.cnf.dimension() < defaultDimension {
+		return nil, ErrInvalidRectangle
+	}
+
+	return neighbors(tree.withinRadius(lat, lon, meters)), nil
+}
+
+// 半径meters以内のリーフエントリーを距離の昇順に返す
+func (tree *RTree) withinRadius(lat, lon, meters float64) (results []nearestItem) {
+	if meters < 0 {
+		return nil
+	}
+
+	metric := tree.cnf.Metric
+	if metric == Euclidean {
+		metric = Haversine
+	}
+
+	parts := metric.splitAntimeridian(tree.expandRectangle(radiusBoundingBox(lat, lon, meters)))
+
+	tree.searchParts(parts, Intersects, func(entry *Node) bool {
+		if distance := metric.minDistance(entry.Rectangle, lat, lon); distance <= meters {
+			results = append(results, nearestItem{node: entry, distance: distance})
+		}
+
+		return true
+	})
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].distance < results[j].distance
+	})
+
+	return
+}
+
+// NewBoundingBox 緯度経度の範囲から短形を作成する
+// Config.MetricがHaversineまたはVincentyの木では、minLon > maxLon の場合は経度180度を跨ぐ範囲 (例: 170度から-170度) として探索される
+func NewBoundingBox(minLat, minLon, maxLat, maxLon float64) Rectangle {
+	return Rectangle{
+		&Inteval{First: minLat, Second: maxLat},
+		&Inteval{First: minLon, Second: maxLon},
+	}
+}
+
+// 緯度経度の距離か
+func (metric Metric) geographic() bool {
+	return metric == Haversine || metric == Vincenty
+}
+
+// 経度180度を跨ぐ短形を東西2つの短形に分割する. 緯度経度の距離でなければ分割しない
+func (metric Metric) splitAntimeridian(rectangle Rectangle) []Rectangle {
+	if !metric.geographic() || len(rectangle) < 2 || rectangle[1].First <= rectangle[1].Second {
+		return []Rectangle{rectangle}
+	}
+
+	east, west := slices.Clone(rectangle), slices.Clone(rectangle)
+	east[1] = &Inteval{First: rectangle[1].First, Second: 180}
+	west[1] = &Inteval{First: -180, Second: rectangle[1].Second}
+
+	return []Rectangle{east, west}
+}
+
+// 木の次元数に満たない短形を、残りの次元を全範囲として拡張する
+func (tree *RTree) expandRectangle(rectangle Rectangle) Rectangle {
+	for dim := len(rectangle); dim < tree.cnf.dimension(); dim++ {
+		rectangle = append(rectangle, &Inteval{First: -math.MaxFloat64, Second: math.MaxFloat64})
+	}
+
+	return rectangle
+}
+
+// 半径metersの円を包む緯度経度の短形. 経度180度を跨ぐ場合は minLon > maxLon となる
+func radiusBoundingBox(lat, lon, meters float64) Rectangle {
+	delta := meters / earthRadius * 180 / math.Pi
+	minLat, maxLat := lat-delta, lat+delta
+
+	// 極を含むなら全経度が対象
+	if maxLat >= 90 || minLat <= -90 {
+		return NewBoundingBox(math.Max(minLat, -90), -180, math.Min(maxLat, 90), 180)
+	}
+
+	sinDelta := math.Sin(meters/earthRadius) / math.Cos(toRadian(lat))
+	if sinDelta >= 1 {
+		return NewBoundingBox(minLat, -180, maxLat, 180)
+	}
+
+	lonDelta := math.Asin(sinDelta) * 180 / math.Pi
+	minLon, maxLon := lon-lonDelta, lon+lonDelta
+
+	switch {
+	case minLon < -180:
+		minLon += 360
+	case 180 < maxLon:
+		maxLon -= 360
+	}
+
+	return NewBoundingBox(minLat, minLon, maxLat, maxLon)
+}
+
+func haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := toRadian(lat1), toRadian(lat2)
+	dPhi := phi2 - phi1
+	dLambda := toRadian(lon2 - lon1)
+
+	h := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(math.Min(1, h)))
+}
+
+// 地点から緯度経度の短形までの大円距離の最小値
+func sphericalMinDistance(rectangle Rectangle, lat, lon float64) float64 {
+	latLow, latHigh := rectangle[0].First, rectangle[0].Second
+	lonLow, lonHigh := rectangle[1].First, rectangle[1].Second
+
+	// 経度が範囲内なら子午線に沿った距離
+	if lonLow <= lon && lon <= lonHigh {
+		switch {
+		case lat < latLow:
+			return toRadian(latLow-lat) * earthRadius
+		case latHigh < lat:
+			return toRadian(lat-latHigh) * earthRadius
+		default:
+			return 0
+		}
+	}
+
+	// 範囲外なら東西いずれかの辺(子午線の線分)が最も近い
+	return math.Min(
+		meridianMinDistance(lat, lon, lonLow, latLow, latHigh),
+		meridianMinDistance(lat, lon, lonHigh, latLow, latHigh),
+	)
+}
+
+// 地点から経度meridianの子午線上の緯度[latLow, latHigh]の線分までの大円距離
+func meridianMinDistance(lat, lon, meridian, latLow, latHigh float64) float64 {
+	phi := toRadian(lat)
+	dLambda := toRadian(lon - meridian)
+
+	// 子午線を含む大円上で最も近い点の緯度
+	foot := math.Atan2(math.Sin(phi), math.Cos(phi)*math.Cos(dLambda)) * 180 / math.Pi
+
+	if latLow <= foot && foot <= latHigh {
+		return haversine(lat, lon, foot, meridian)
+	}
+
+	return math.Min(haversine(lat, lon, latLow, meridian), haversine(lat, lon, latHigh, meridian))
+}
+
+// Vincentyの逆解法. 収束しない(対蹠点付近)場合は大円距離を返す
+func vincenty(lat1, lon1, lat2, lon2 float64) float64 {
+	if lat1 == lat2 && lon1 == lon2 {
+		return 0
+	}
+
+	l := toRadian(lon2 - lon1)
+	u1 := math.Atan((1 - wgs84F) * math.Tan(toRadian(lat1)))
+	u2 := math.Atan((1 - wgs84F) * math.Tan(toRadian(lat2)))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+
+	lambda := l
+
+	for i := 0; i < vincentyMaxIteration; i++ {
+		sinLambda, cosLambda := math.Sincos(lambda)
+
+		sinSigma := math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			return 0
+		}
+
+		cosSigma := sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma := math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha := 1 - sinAlpha*sinAlpha
+
+		// 赤道上の線
+		cos2SigmaM := 0.0
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+
+		c := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = l + (1-c)*wgs84F*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+		if math.Abs(lambda-prev) < 1e-12 {
+			uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+			a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+			b := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+			deltaSigma := b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+				b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+
+			return wgs84B * a * (sigma - deltaSigma)
+		}
+	}
+
+	return haversine(lat1, lon1, lat2, lon2)
+}
+
+func toRadian(degree float64) float64 {
+	return degree * math.Pi / 180
+}
@@ -0,0 +1,141 @@
+package rtree_test
+
+import (
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	t.Run("haversine", func(t *testing.T) {
+		// 東京駅 - 新大阪駅 約403km
+		d := rtree.Haversine.Distance(35.681236, 139.767125, 34.733165, 135.500214)
+		assert.InDelta(t, 403000, d, 2000)
+	})
+
+	t.Run("vincenty", func(t *testing.T) {
+		// Flinders Peak - Buninyong (Vincenty 1975 の検証値)
+		d := rtree.Vincenty.Distance(
+			-(37 + 57.0/60 + 3.72030/3600), 144+25.0/60+29.52440/3600,
+			-(37 + 39.0/60 + 10.15610/3600), 143+55.0/60+35.38390/3600,
+		)
+		assert.InDelta(t, 54972.271, d, 0.01)
+		assert.Zero(t, rtree.Vincenty.Distance(35, 139, 35, 139))
+	})
+
+	t.Run("euclidean", func(t *testing.T) {
+		assert.EqualValues(t, 5, rtree.Euclidean.Distance(0, 0, 3, 4))
+	})
+}
+
+func TestGeodesicQuery(t *testing.T) {
+	stations := []struct {
+		id       uint64
+		lat, lon float64
+	}{
+		{1, 35.681236, 139.767125}, // 東京
+		{2, 35.690921, 139.700258}, // 新宿
+		{3, 35.628471, 139.738760}, // 品川
+		{4, 35.658034, 139.701636}, // 渋谷
+		{5, 34.733165, 135.500214}, // 新大阪
+		{6, 43.068661, 141.350755}, // 札幌
+	}
+
+	for _, metric := range []rtree.Metric{rtree.Haversine, rtree.Vincenty} {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2, Metric: metric})
+
+		for _, s := range stations {
+			_ = tree.AddNode(tree.TakePlace(s.id, s.lat, s.lon))
+		}
+
+		t.Run("nearest", func(t *testing.T) {
+			results, err := tree.Nearest(35.681236, 139.767125, 3)
+			assert.NoError(t, err)
+			assert.Len(t, results, 3)
+			assert.EqualValues(t, 1, results[0].ID)
+			assert.EqualValues(t, 2, results[1].ID)
+			assert.EqualValues(t, 3, results[2].ID)
+			assert.InDelta(t, 6400, results[2].Distance, 100)
+		})
+
+		t.Run("within radius", func(t *testing.T) {
+			results, err := tree.WithinRadius(35.681236, 139.767125, 7000)
+			assert.NoError(t, err)
+
+			ids := make([]uint64, 0, len(results))
+			for _, r := range results {
+				ids = append(ids, r.ID)
+			}
+
+			assert.Equal(t, []uint64{1, 2, 3, 4}, ids)
+
+			results, err = tree.WithinRadius(35.681236, 139.767125, 500000)
+			assert.NoError(t, err)
+			assert.Len(t, results, 5)
+		})
+	}
+}
+
+func TestAntimeridian(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2, Metric: rtree.Haversine})
+
+	_ = tree.AddNode(tree.TakePlace(1, -18.14, 178.44))  // スバ
+	_ = tree.AddNode(tree.TakePlace(2, -13.83, -171.76)) // アピア
+	_ = tree.AddNode(tree.TakePlace(3, -21.14, -175.20)) // ヌクアロファ
+	_ = tree.AddNode(tree.TakePlace(4, 35.68, 139.77))   // 東京
+	_ = tree.AddNode(tree.TakePlace(5, 21.31, -157.86))  // ホノルル
+
+	t.Run("search wrapped box", func(t *testing.T) {
+		ids, err := tree.Search(rtree.NewBoundingBox(-30, 170, 0, -170))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, ids)
+
+		ids, err = tree.Search(rtree.NewBoundingBox(-30, 170, 0, -170), rtree.WithLimit(2))
+		assert.NoError(t, err)
+		assert.Len(t, ids, 2)
+	})
+
+	t.Run("contains wrapped box", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2, Metric: rtree.Haversine})
+
+		east := tree.TakePlace(1, 0, 0)
+		east.Rectangle = rtree.NewBoundingBox(-10, 170, 10, 180)
+		world := tree.TakePlace(2, 0, 0)
+		world.Rectangle = rtree.NewBoundingBox(-90, -180, 90, 180)
+
+		_ = tree.AddNode(east)
+		_ = tree.AddNode(world)
+
+		ids, err := tree.Search(rtree.NewBoundingBox(-1, 175, 1, -175), rtree.WithMode(rtree.Contains))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{2}, ids)
+	})
+
+	t.Run("euclidean does not wrap", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		_ = tree.AddNode(tree.TakePlace(1, -18.14, 178.44))
+		_ = tree.AddNode(tree.TakePlace(2, -13.83, -171.76))
+
+		ids, err := tree.Search(rtree.NewBoundingBox(-30, 170, 0, -170))
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("radius across antimeridian", func(t *testing.T) {
+		// スバからヌクアロファまで約800km
+		results, err := tree.WithinRadius(-18.14, 178.44, 900000)
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+		assert.EqualValues(t, 1, results[0].ID)
+		assert.EqualValues(t, 3, results[1].ID)
+	})
+
+	t.Run("nearest across antimeridian", func(t *testing.T) {
+		results, err := tree.Nearest(-18.14, 179.9, 2)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, results[0].ID)
+		assert.EqualValues(t, 3, results[1].ID)
+	})
+}
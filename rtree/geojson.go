@@ -0,0 +1,423 @@
+package rtree
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+)
+
+type (
+	// Properties GeoJSONのFeatureのproperties
+	Properties map[string]any
+
+	GeoJSONOption func(*geoJSONOption)
+
+	geoJSONOption struct {
+		nodes bool
+	}
+
+	geoJSONObject struct {
+		Type     string           `json:"type"`
+		Features []geoJSONFeature `json:"features,omitempty"`
+		// 単一のFeature
+		geoJSONFeature
+	}
+
+	geoJSONFeature struct {
+		ID         json.RawMessage  `json:"id,omitempty"`
+		Geometry   *geoJSONGeometry `json:"geometry"`
+		Properties Properties       `json:"properties"`
+	}
+
+	geoJSONGeometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+
+	// 座標は経度, 緯度の順
+	geoJSONPosition []float64
+)
+
+var ErrInvalidGeoJSON = errors.New("rtree: invalid geojson")
+
+// GeoJSONIDProperty 整数でないFeatureのidを保持するpropertiesのキー
+const GeoJSONIDProperty = "@id"
+
+// WithNodes 中間ノードの短形も書き出す. 木の構造を確認するためのもの
+func WithNodes() GeoJSONOption {
+	return func(o *geoJSONOption) {
+		o.nodes = true
+	}
+}
+
+// ReadGeoJSON GeoJSONのFeatureCollection(またはFeature)から木を作成する. propertiesは値として保持する
+// 対応する形状は Point, LineString, Polygon, MultiPolygon. geometryがnullのFeatureは読み飛ばす
+// Featureのidが整数ならDataIDとする. それ以外は出現順の番号から未使用のものを割り当て、元のidは GeoJSONIDProperty に残す
+// 誤りは何番目のFeatureかを付けて返す
+func ReadGeoJSON(cnf *Config, r io.Reader) (tree *Tree[Properties], err error) {
+	var object geoJSONObject
+	if err = json.NewDecoder(r).Decode(&object); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidGeoJSON, err)
+	}
+
+	features := object.Features
+
+	switch object.Type {
+	case "FeatureCollection":
+	case "Feature":
+		features = []geoJSONFeature{object.geoJSONFeature}
+	default:
+		return nil, fmt.Errorf("%w: unsupported type %q", ErrInvalidGeoJSON, object.Type)
+	}
+
+	tree = NewTree[Properties](cnf)
+
+	// 整数のidを先に集め、割り当てる番号と重ならないようにする
+	used := make(map[uint64]bool)
+
+	for i, feature := range features {
+		if id, ok := feature.integerID(); ok && feature.Geometry != nil {
+			if used[id] {
+				return nil, fmt.Errorf("feature %d: %w", i, ErrDuplicateID)
+			}
+
+			used[id] = true
+		}
+	}
+
+	for i, feature := range features {
+		if feature.Geometry == nil {
+			continue
+		}
+
+		id, ok := feature.integerID()
+		if !ok {
+			id = uint64(i)
+			for used[id] {
+				id++
+			}
+
+			used[id] = true
+
+			if original := feature.originalID(); original != nil {
+				if feature.Properties == nil {
+					feature.Properties = Properties{}
+				}
+
+				feature.Properties[GeoJSONIDProperty] = original
+			}
+		}
+
+		node, err := feature.Geometry.node(tree.tree, id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: feature %d: %w", ErrInvalidGeoJSON, i, err)
+		}
+
+		if err = tree.add(node, feature.Properties); err != nil {
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+	}
+
+	return
+}
+
+// 整数のid
+func (feature geoJSONFeature) integerID() (uint64, bool) {
+	id, err := strconv.ParseUint(string(feature.ID), 10, 64)
+
+	return id, err == nil
+}
+
+// idの値. 文字列のidは文字列、数値のidは数値となる. idが無ければnil
+func (feature geoJSONFeature) originalID() (id any) {
+	_ = json.Unmarshal(feature.ID, &id)
+
+	return
+}
+
+// WriteGeoJSON リーフエントリーをDataID順のFeatureCollectionとして書き出す. propertiesはnullとする
+func (tree *RTree) WriteGeoJSON(w io.Writer, opts ...GeoJSONOption) error {
+	return tree.writeGeoJSON(w, opts, func(uint64) any { return nil })
+}
+
+// WriteGeoJSON RTree.WriteGeoJSON の値を書き出す版
+// 値が Properties またはJSONオブジェクトになる値ならpropertiesとして書き出す
+func (t *Tree[T]) WriteGeoJSON(w io.Writer, opts ...GeoJSONOption) error {
+	return t.tree.writeGeoJSON(w, opts, func(id uint64) any { return t.values[id] })
+}
+
+// リーフエントリーと値をFeatureCollectionとして書き出す. valueはDataIDの値
+func (tree *RTree) writeGeoJSON(w io.Writer, opts []GeoJSONOption, value func(id uint64) any) (err error) {
+	if tree.cnf.dimension() != defaultDimension {
+		return ErrInvalidRectangle
+	}
+
+	o := new(geoJSONOption)
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	type feature struct {
+		Type       string           `json:"type"`
+		ID         *uint64          `json:"id,omitempty"`
+		Geometry   *geoJSONGeometry `json:"geometry"`
+		Properties any              `json:"properties"`
+	}
+
+	features := make([]feature, 0, len(tree.entries))
+
+	ids := make([]uint64, 0, len(tree.entries))
+	for id := range tree.entries {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+
+	for _, id := range ids {
+		entry := tree.entries[id]
+
+		geometry, err := encodeGeometry(entry)
+		if err != nil {
+			return err
+		}
+
+		features = append(features, feature{Type: "Feature", ID: entry.DataID, Geometry: geometry, Properties: properties(value(id))})
+	}
+
+	if o.nodes {
+		var walk func(node *Node, depth int) error
+
+		walk = func(node *Node, depth int) error {
+			geometry, err := encodeGeometry(&Node{Rectangle: node.Rectangle})
+			if err != nil {
+				return err
+			}
+
+			features = append(features, feature{
+				Type:       "Feature",
+				Geometry:   geometry,
+				Properties: Properties{"depth": depth, "children": len(node.Children)},
+			})
+
+			for _, child := range node.Children {
+				if child.DataID == nil {
+					if err := walk(child, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+
+			return nil
+		}
+
+		if err = walk(tree.Root, 0); err != nil {
+			return err
+		}
+	}
+
+	return json.NewEncoder(w).Encode(struct {
+		Type     string    `json:"type"`
+		Features []feature `json:"features"`
+	}{Type: "FeatureCollection", Features: features})
+}
+
+// 形状からリーフエントリーを作成する
+func (geometry *geoJSONGeometry) node(tree *RTree, id uint64) (node *Node, err error) {
+	switch geometry.Type {
+	case "Point":
+		var position geoJSONPosition
+		if err = geometry.decode(&position); err != nil {
+			return nil, err
+		}
+
+		p, err := position.point()
+		if err != nil {
+			return nil, err
+		}
+
+		return tree.TakePlace(id, p.Lat, p.Lon), nil
+	case "LineString":
+		var positions []geoJSONPosition
+		if err = geometry.decode(&positions); err != nil {
+			return nil, err
+		}
+
+		line, err := points(positions, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return tree.TakeLineString(id, line), nil
+	case "Polygon":
+		var rings [][]geoJSONPosition
+		if err = geometry.decode(&rings); err != nil {
+			return nil, err
+		}
+
+		polygon, err := decodePolygon(rings)
+		if err != nil {
+			return nil, err
+		}
+
+		return tree.TakePolygon(id, polygon), nil
+	case "MultiPolygon":
+		var polygons [][][]geoJSONPosition
+		if err = geometry.decode(&polygons); err != nil {
+			return nil, err
+		}
+
+		if len(polygons) == 0 {
+			return nil, errors.New("empty multipolygon")
+		}
+
+		multi := make(MultiPolygon, len(polygons))
+		for i, rings := range polygons {
+			if multi[i], err = decodePolygon(rings); err != nil {
+				return nil, err
+			}
+		}
+
+		return tree.TakeGeometry(id, multi), nil
+	default:
+		return nil, fmt.Errorf("unsupported geometry %q", geometry.Type)
+	}
+}
+
+func (geometry *geoJSONGeometry) decode(coordinates any) error {
+	return json.Unmarshal(geometry.Coordinates, coordinates)
+}
+
+func decodePolygon(rings [][]geoJSONPosition) (polygon Polygon, err error) {
+	if len(rings) == 0 {
+		return polygon, errors.New("empty polygon")
+	}
+
+	exterior, err := points(rings[0], 3)
+	if err != nil {
+		return polygon, err
+	}
+
+	polygon.Exterior = Ring(exterior)
+
+	for _, positions := range rings[1:] {
+		hole, err := points(positions, 3)
+		if err != nil {
+			return polygon, err
+		}
+
+		polygon.Holes = append(polygon.Holes, Ring(hole))
+	}
+
+	return
+}
+
+// 座標列を点列にする. 点の数がminSizeに満たなければエラー
+func points(positions []geoJSONPosition, minSize int) (points []Point, err error) {
+	if len(positions) < minSize {
+		return nil, fmt.Errorf("too few positions %d", len(positions))
+	}
+
+	points = make([]Point, len(positions))
+
+	for i, position := range positions {
+		if points[i], err = position.point(); err != nil {
+			return nil, err
+		}
+	}
+
+	return
+}
+
+func (position geoJSONPosition) point() (Point, error) {
+	if len(position) < 2 {
+		return Point{}, fmt.Errorf("invalid position %v", []float64(position))
+	}
+
+	return Point{Lat: position[1], Lon: position[0]}, nil
+}
+
+// リーフエントリーの形状. 形状を持たない場合は点または短形の多角形とする
+func encodeGeometry(entry *Node) (geometry *geoJSONGeometry, err error) {
+	geometry = new(geoJSONGeometry)
+
+	var coordinates any
+
+	switch g := entry.Geometry.(type) {
+	case LineString:
+		geometry.Type, coordinates = "LineString", positions(g)
+	case Polygon:
+		geometry.Type, coordinates = "Polygon", g.positions()
+	case MultiPolygon:
+		polygons := make([][][][2]float64, len(g))
+		for i := range g {
+			polygons[i] = g[i].positions()
+		}
+
+		geometry.Type, coordinates = "MultiPolygon", polygons
+	default:
+		rectangle := entry.Rectangle
+		if g != nil {
+			rectangle = g.Bounds()
+		}
+
+		if rectangle.area() == 0 && rectangle.margin() == 0 {
+			geometry.Type, coordinates = "Point", [2]float64{rectangle[1].First, rectangle[0].First}
+			break
+		}
+
+		geometry.Type = "Polygon"
+		coordinates = Polygon{Exterior: Ring{
+			{rectangle[0].First, rectangle[1].First},
+			{rectangle[0].First, rectangle[1].Second},
+			{rectangle[0].Second, rectangle[1].Second},
+			{rectangle[0].Second, rectangle[1].First},
+		}}.positions()
+	}
+
+	geometry.Coordinates, err = json.Marshal(coordinates)
+
+	return
+}
+
+// GeoJSONの環は始点と終点が同じ
+func (polygon Polygon) positions() (rings [][][2]float64) {
+	for _, ring := range append([]Ring{polygon.Exterior}, polygon.Holes...) {
+		if 0 < len(ring) && ring[0] != ring[len(ring)-1] {
+			ring = append(slices.Clip(ring), ring[0])
+		}
+
+		rings = append(rings, positions(ring))
+	}
+
+	return
+}
+
+func positions(points []Point) (positions [][2]float64) {
+	positions = make([][2]float64, len(points))
+
+	for i, p := range points {
+		positions[i] = [2]float64{p.Lon, p.Lat}
+	}
+
+	return
+}
+
+// 値をpropertiesにする. JSONオブジェクトにならない値は書き出さない
+func properties(value any) any {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case Properties, map[string]any:
+		return v
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil || len(b) == 0 || b[0] != '{' {
+		return nil
+	}
+
+	return json.RawMessage(b)
+}
@@ -0,0 +1,214 @@
+package rtree_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"rtree"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const featureCollection = `{
+  "type": "FeatureCollection",
+  "features": [
+    {"type": "Feature", "id": 10, "geometry": {"type": "Point", "coordinates": [139.767125, 35.681236]}, "properties": {"name": "東京駅"}},
+    {"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[139.70, 35.69], [139.77, 35.68]]}, "properties": {"name": "線"}},
+    {"type": "Feature", "id": "ward", "geometry": {"type": "Polygon", "coordinates": [
+      [[139.0, 35.0], [140.0, 35.0], [140.0, 36.0], [139.0, 36.0], [139.0, 35.0]],
+      [[139.4, 35.4], [139.6, 35.4], [139.6, 35.6], [139.4, 35.6], [139.4, 35.4]]
+    ]}, "properties": {"name": "区"}},
+    {"type": "Feature", "id": 20, "geometry": {"type": "MultiPolygon", "coordinates": [
+      [[[135.0, 34.0], [136.0, 34.0], [136.0, 35.0], [135.0, 34.0]]],
+      [[[130.0, 33.0], [131.0, 33.0], [131.0, 34.0], [130.0, 33.0]]]
+    ]}, "properties": {"name": "島"}},
+    {"type": "Feature", "id": 30, "geometry": null, "properties": {"name": "位置なし"}}
+  ]
+}`
+
+func TestReadGeoJSON(t *testing.T) {
+	tree, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(featureCollection))
+	assert.NoError(t, err)
+
+	t.Run("features", func(t *testing.T) {
+		for id, name := range map[uint64]string{10: "東京駅", 1: "線", 2: "区", 20: "島"} {
+			properties, ok := tree.Get(id)
+			assert.True(t, ok, id)
+			assert.Equal(t, name, properties["name"])
+		}
+
+		_, ok := tree.Get(30)
+		assert.False(t, ok)
+	})
+
+	t.Run("geometry", func(t *testing.T) {
+		// 座標は経度, 緯度の順
+		values, _ := tree.Search(rtree.NewBoundingBox(35.68, 139.76, 35.69, 139.77))
+		assert.Len(t, values, 3)
+
+		values, _ = tree.Locate(35.5, 139.5)
+		assert.Empty(t, values, "穴")
+
+		values, _ = tree.Locate(33.2, 130.5)
+		assert.Equal(t, "島", values[0]["name"])
+
+		values, _ = tree.Locate(33.8, 130.5)
+		assert.Empty(t, values)
+	})
+
+	t.Run("feature", func(t *testing.T) {
+		tree, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(
+			`{"type": "Feature", "id": 5, "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": null}`,
+		))
+		assert.NoError(t, err)
+
+		ids, _ := tree.RTree().Search(rtree.NewBoundingBox(2, 1, 2, 1))
+		assert.Equal(t, []uint64{5}, ids)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		for _, s := range []string{
+			`{"type": "Point", "coordinates": [1, 2]}`,
+			`{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[1, 2]]}}`,
+			`{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1]}}`,
+			`{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[[1, 2], [3, 4]]]}}`,
+			`{"type": "FeatureCollection", "features": [`,
+		} {
+			_, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(s))
+			assert.ErrorIs(t, err, rtree.ErrInvalidGeoJSON, s)
+		}
+
+		_, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(
+			`{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "id": 1, "geometry": {"type": "Point", "coordinates": [1, 2]}},
+				{"type": "Feature", "id": 1, "geometry": {"type": "Point", "coordinates": [1, 2]}}
+			]}`,
+		))
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+		assert.ErrorContains(t, err, "feature 1")
+
+		_, err = rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(
+			`{"type": "FeatureCollection", "features": [
+				{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}},
+				{"type": "Feature", "geometry": {"type": "Polygon", "coordinates": [[]]}}
+			]}`,
+		))
+		assert.ErrorIs(t, err, rtree.ErrInvalidGeoJSON)
+		assert.ErrorContains(t, err, "feature 1")
+	})
+
+	t.Run("assigned ids", func(t *testing.T) {
+		for _, c := range []struct {
+			features string
+			ids      map[uint64]any // 割り当てられたidと元のid
+		}{
+			{
+				features: `{"id": 1, "geometry": {"type": "Point", "coordinates": [1, 2]}},
+					{"geometry": {"type": "Point", "coordinates": [1, 2]}}`,
+				ids: map[uint64]any{1: nil, 2: nil},
+			},
+			{
+				features: `{"id": "a", "geometry": {"type": "Point", "coordinates": [1, 2]}},
+					{"id": 0, "geometry": {"type": "Point", "coordinates": [1, 2]}}`,
+				ids: map[uint64]any{0: nil, 1: "a"},
+			},
+			{
+				features: `{"id": "b", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {"name": "b"}},
+					{"id": 1.5, "geometry": {"type": "Point", "coordinates": [1, 2]}}`,
+				ids: map[uint64]any{0: "b", 1: 1.5},
+			},
+		} {
+			tree, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(
+				`{"type": "FeatureCollection", "features": [`+c.features+`]}`,
+			))
+			assert.NoError(t, err, c.features)
+
+			for id, original := range c.ids {
+				properties, ok := tree.Get(id)
+				assert.True(t, ok, id)
+				assert.Equal(t, original, properties[rtree.GeoJSONIDProperty], id)
+			}
+		}
+	})
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	tree, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 2}, strings.NewReader(featureCollection))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.WriteGeoJSON(&buf))
+
+	t.Run("round trip", func(t *testing.T) {
+		loaded, err := rtree.ReadGeoJSON(&rtree.Config{MaxEntrySize: 4}, bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+
+		for _, id := range []uint64{1, 2, 10, 20} {
+			expected, _ := tree.Get(id)
+			actual, ok := loaded.Get(id)
+			assert.True(t, ok)
+			assert.Equal(t, expected, actual)
+		}
+
+		for _, p := range [][2]float64{{35.5, 139.5}, {35.1, 139.1}, {33.2, 130.5}, {34.9, 135.5}} {
+			expected, _ := tree.Locate(p[0], p[1])
+			actual, _ := loaded.Locate(p[0], p[1])
+			assert.ElementsMatch(t, expected, actual, p)
+		}
+	})
+
+	t.Run("nodes", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, tree.RTree().WriteGeoJSON(&buf, rtree.WithNodes()))
+
+		var collection struct {
+			Features []struct {
+				ID       *uint64 `json:"id"`
+				Geometry struct {
+					Type string `json:"type"`
+				} `json:"geometry"`
+				Properties map[string]any `json:"properties"`
+			} `json:"features"`
+		}
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+
+		var leaves, nodes int
+
+		for _, f := range collection.Features {
+			if f.ID != nil {
+				leaves++
+				continue
+			}
+
+			// 1点のみを包むノードはPointになる
+			nodes++
+			assert.Contains(t, []string{"Polygon", "Point"}, f.Geometry.Type)
+			assert.Contains(t, f.Properties, "depth")
+		}
+
+		assert.Equal(t, 4, leaves)
+		assert.Less(t, 1, nodes)
+	})
+
+	t.Run("without values", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, tree.RTree().WriteGeoJSON(&buf))
+		assert.NotContains(t, buf.String(), "東京駅")
+	})
+
+	t.Run("values", func(t *testing.T) {
+		type poi struct {
+			Name string `json:"name"`
+		}
+
+		tree := rtree.NewTree[poi](&rtree.Config{MaxEntrySize: 2})
+		_ = tree.Insert(1, rtree.NewBoundingBox(1, 2, 3, 4), poi{Name: "a"})
+
+		var buf bytes.Buffer
+		assert.NoError(t, tree.WriteGeoJSON(&buf))
+		assert.JSONEq(t, `{"type": "FeatureCollection", "features": [
+			{"type": "Feature", "id": 1, "geometry": {"type": "Polygon", "coordinates": [[[2, 1], [4, 1], [4, 3], [2, 3], [2, 1]]]}, "properties": {"name": "a"}}
+		]}`, buf.String())
+	})
+}
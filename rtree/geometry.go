@@ -0,0 +1,493 @@
+package rtree
+
+import (
+	"math"
+	"slices"
+)
+
+type (
+	// Geometry リーフエントリーの厳密な形状. 木には外接短形で格納し、探索の最後に形状で判定する
+	// 座標は緯度を0次元目、経度を1次元目とする平面として扱う
+	Geometry interface {
+		// Bounds 最小外接短形
+		Bounds() Rectangle
+		// Intersects 短形と共有点を持つか判定
+		Intersects(rectangle Rectangle) bool
+		// Covers 短形全体を含むか判定 (境界上も含む)
+		Covers(rectangle Rectangle) bool
+	}
+
+	Point struct {
+		Lat float64
+		Lon float64
+	}
+
+	// LineString 折れ線
+	LineString []Point
+
+	// Ring 閉じた線. 始点と終点は同じでも異なってもよい
+	Ring []Point
+
+	// Polygon 外周と穴からなる多角形
+	Polygon struct {
+		Exterior Ring
+		Holes    []Ring
+	}
+
+	// MultiPolygon 複数の多角形. 各多角形の内部は重ならないものとする
+	MultiPolygon []Polygon
+
+	segment struct {
+		a, b Point
+	}
+)
+
+// TakeRectangle 短形のリーフエントリーを作成する
+func (tree *RTree) TakeRectangle(id uint64, rectangle Rectangle) (node *Node) {
+	node = tree.NewNode(nil)
+	node.Rectangle = rectangle.clone()
+	node.DataID = &id
+
+	return
+}
+
+// TakeGeometry 形状のリーフエントリーを作成する. 短形は形状の外接短形となる
+func (tree *RTree) TakeGeometry(id uint64, geometry Geometry) (node *Node) {
+	node = tree.TakeRectangle(id, geometry.Bounds())
+	node.Geometry = geometry
+
+	return
+}
+
+func (tree *RTree) TakePolygon(id uint64, polygon Polygon) *Node {
+	return tree.TakeGeometry(id, polygon)
+}
+
+func (tree *RTree) TakeLineString(id uint64, line LineString) *Node {
+	return tree.TakeGeometry(id, line)
+}
+
+// UpdateGeometry DataIDのリーフエントリーの形状を置き換える
+func (tree *RTree) UpdateGeometry(id uint64, geometry Geometry) (err error) {
+	entry, ok := tree.entries[id]
+	if !ok {
+		return ErrNotFound
+	}
+
+	if err = tree.Update(id, geometry.Bounds()); err != nil {
+		return err
+	}
+
+	entry.Geometry = geometry
+
+	return
+}
+
+func (line LineString) Bounds() Rectangle {
+	return bounds(line)
+}
+
+func (line LineString) Intersects(rectangle Rectangle) bool {
+	for _, s := range line.segments() {
+		if s.clip(rectangle, false) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers 面積を持つ短形は含まない. 点や線分の短形は折れ線上にあれば含む
+func (line LineString) Covers(rectangle Rectangle) bool {
+	if 0 < rectangle.area() {
+		return false
+	}
+
+	segments := line.segments()
+
+	onLine := func(p Point) bool {
+		for _, s := range segments {
+			if s.contains(p) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	for _, side := range sides(rectangle) {
+		if !side.coveredBy(segments, onLine) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (line LineString) segments() (segments []segment) {
+	if len(line) == 1 {
+		return []segment{{line[0], line[0]}}
+	}
+
+	for i := 1; i < len(line); i++ {
+		segments = append(segments, segment{line[i-1], line[i]})
+	}
+
+	return
+}
+
+func (polygon Polygon) Bounds() Rectangle {
+	return bounds(polygon.Exterior)
+}
+
+func (polygon Polygon) Intersects(rectangle Rectangle) bool {
+	for _, s := range polygon.segments() {
+		if s.clip(rectangle, false) {
+			return true
+		}
+	}
+
+	// 辺が交わらなければ、短形が多角形の内側にあるかどうか
+	return polygon.contains(center(rectangle))
+}
+
+func (polygon Polygon) Covers(rectangle Rectangle) bool {
+	segments := polygon.segments()
+
+	// 短形の辺が全て多角形に含まれ、短形の内部に多角形の境界が無いこと
+	for _, side := range sides(rectangle) {
+		if !side.coveredBy(segments, polygon.contains) {
+			return false
+		}
+	}
+
+	for _, s := range segments {
+		if s.clip(rectangle, true) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ContainsPoint 点が多角形に含まれるか判定. 境界上の点も含み、穴の内側は含まない
+func (polygon Polygon) ContainsPoint(lat, lon float64) bool {
+	return polygon.contains(Point{Lat: lat, Lon: lon})
+}
+
+func (polygon Polygon) contains(p Point) bool {
+	for _, s := range polygon.segments() {
+		if s.contains(p) {
+			return true
+		}
+	}
+
+	if polygon.Exterior.winding(p) == 0 {
+		return false
+	}
+
+	for _, hole := range polygon.Holes {
+		if hole.winding(p) != 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (multi MultiPolygon) Bounds() Rectangle {
+	var points []Point
+
+	for _, polygon := range multi {
+		points = append(points, polygon.Exterior...)
+	}
+
+	return bounds(points)
+}
+
+func (multi MultiPolygon) Intersects(rectangle Rectangle) bool {
+	for _, polygon := range multi {
+		if polygon.Intersects(rectangle) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers いずれかの多角形が短形全体を含むか判定. 複数の多角形にまたがる短形は含まない
+func (multi MultiPolygon) Covers(rectangle Rectangle) bool {
+	for _, polygon := range multi {
+		if polygon.Covers(rectangle) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (multi MultiPolygon) ContainsPoint(lat, lon float64) bool {
+	for _, polygon := range multi {
+		if polygon.ContainsPoint(lat, lon) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (polygon Polygon) segments() (segments []segment) {
+	segments = polygon.Exterior.segments()
+
+	for _, hole := range polygon.Holes {
+		segments = append(segments, hole.segments()...)
+	}
+
+	return
+}
+
+func (ring Ring) segments() (segments []segment) {
+	for i := range ring {
+		next := ring[(i+1)%len(ring)]
+		if ring[i] != next {
+			segments = append(segments, segment{ring[i], next})
+		}
+	}
+
+	return
+}
+
+// 点に対する環の回転数. 0なら外側. 環の向きや自己交差によらず判定できる. 境界上の点の結果は不定
+func (ring Ring) winding(p Point) (winding int) {
+	for _, s := range ring.segments() {
+		switch {
+		case s.a.Lon <= p.Lon && p.Lon < s.b.Lon && 0 < orientation(s.a, s.b, p):
+			winding++
+		case s.b.Lon <= p.Lon && p.Lon < s.a.Lon && orientation(s.a, s.b, p) < 0:
+			winding--
+		}
+	}
+
+	return
+}
+
+// Liang-Barsky: 線分が短形と共有点を持つか判定. openなら短形の内部(境界を除く)と交わるか判定する
+func (s segment) clip(rectangle Rectangle, open bool) bool {
+	t0, t1 := 0.0, 1.0
+	d := [2]float64{s.b.Lat - s.a.Lat, s.b.Lon - s.a.Lon}
+	a := [2]float64{s.a.Lat, s.a.Lon}
+
+	for dim := 0; dim < 2; dim++ {
+		for _, c := range [2][2]float64{
+			{-d[dim], a[dim] - rectangle[dim].First},
+			{d[dim], rectangle[dim].Second - a[dim]},
+		} {
+			p, q := c[0], c[1]
+
+			if p == 0 {
+				if q < 0 || (open && q == 0) {
+					return false
+				}
+
+				continue
+			}
+
+			t := q / p
+			if p < 0 {
+				t0 = max(t0, t)
+			} else {
+				t1 = min(t1, t)
+			}
+		}
+	}
+
+	if open {
+		return t0 < t1
+	}
+
+	return t0 <= t1
+}
+
+// 形状同士が共有点を持つか判定. 辺が交わるか、一方の面が他方の頂点を含めば共有点を持つ
+// 形状の種類が不明ならokはfalse
+func intersectGeometries(a, b Geometry) (intersects, ok bool) {
+	as, aContains, ok := outline(a)
+	if !ok {
+		return false, false
+	}
+
+	bs, bContains, ok := outline(b)
+	if !ok {
+		return false, false
+	}
+
+	for _, s := range as {
+		for _, other := range bs {
+			if s.intersects(other) {
+				return true, true
+			}
+		}
+	}
+
+	// 辺が交わらなければ、一方が他方の内側にある場合だけ共有点を持つ
+	if aContains != nil && 0 < len(bs) && aContains(bs[0].a) {
+		return true, true
+	}
+
+	if bContains != nil && 0 < len(as) && bContains(as[0].a) {
+		return true, true
+	}
+
+	return false, true
+}
+
+// 形状の辺と、面を持つ形状なら点を含むかの判定
+func outline(geometry Geometry) (segments []segment, contains func(p Point) bool, ok bool) {
+	switch g := geometry.(type) {
+	case LineString:
+		return g.segments(), nil, true
+	case Polygon:
+		return g.segments(), g.contains, true
+	case MultiPolygon:
+		for _, polygon := range g {
+			segments = append(segments, polygon.segments()...)
+		}
+
+		return segments, func(p Point) bool { return g.ContainsPoint(p.Lat, p.Lon) }, true
+	}
+
+	return nil, nil, false
+}
+
+// 他の線分と共有点を持つか判定. 長さ0の線分は点として扱う
+func (s segment) intersects(other segment) bool {
+	switch {
+	case s.a == s.b:
+		return other.contains(s.a)
+	case other.a == other.b:
+		return s.contains(other.a)
+	}
+
+	// 同一直線上で他方の内側にある場合は crossings が端点を返さない
+	return 0 < len(s.crossings(other)) || other.contains(s.a)
+}
+
+// 点が線分上にあるか判定
+func (s segment) contains(p Point) bool {
+	return orientation(s.a, s.b, p) == 0 &&
+		min(s.a.Lat, s.b.Lat) <= p.Lat && p.Lat <= max(s.a.Lat, s.b.Lat) &&
+		min(s.a.Lon, s.b.Lon) <= p.Lon && p.Lon <= max(s.a.Lon, s.b.Lon)
+}
+
+// 線分全体がinsideを満たすか判定. 他の線分との交点で区切り、各区間の端点と中点を調べる
+func (s segment) coveredBy(segments []segment, inside func(p Point) bool) bool {
+	ts := []float64{0, 1}
+
+	for _, other := range segments {
+		ts = append(ts, s.crossings(other)...)
+	}
+
+	slices.Sort(ts)
+
+	for i, t := range ts {
+		if !inside(s.at(t)) {
+			return false
+		}
+
+		if 0 < i && ts[i-1] < t && !inside(s.at((ts[i-1]+t)/2)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// 他の線分と交わる位置 (0..1). 重なる場合は重なりの両端
+func (s segment) crossings(other segment) (ts []float64) {
+	d1 := orientation(other.a, other.b, s.a)
+	d2 := orientation(other.a, other.b, s.b)
+	d3 := orientation(s.a, s.b, other.a)
+	d4 := orientation(s.a, s.b, other.b)
+
+	if d1 == 0 && d2 == 0 {
+		// 同一直線上: 重なりの両端を射影する
+		for _, p := range []Point{other.a, other.b} {
+			if t := s.project(p); 0 <= t && t <= 1 {
+				ts = append(ts, t)
+			}
+		}
+
+		return
+	}
+
+	if (d1 > 0 && d2 > 0) || (d1 < 0 && d2 < 0) || (d3 > 0 && d4 > 0) || (d3 < 0 && d4 < 0) {
+		return nil
+	}
+
+	return []float64{d1 / (d1 - d2)}
+}
+
+// 線分上の位置tの点
+func (s segment) at(t float64) Point {
+	return Point{Lat: s.a.Lat + (s.b.Lat-s.a.Lat)*t, Lon: s.a.Lon + (s.b.Lon-s.a.Lon)*t}
+}
+
+// 点を線分の直線へ射影した位置
+func (s segment) project(p Point) float64 {
+	dLat, dLon := s.b.Lat-s.a.Lat, s.b.Lon-s.a.Lon
+
+	length := dLat*dLat + dLon*dLon
+	if length == 0 {
+		return 0
+	}
+
+	return ((p.Lat-s.a.Lat)*dLat + (p.Lon-s.a.Lon)*dLon) / length
+}
+
+// 3点の向き. 正なら反時計回り、0なら同一直線上
+func orientation(a, b, c Point) float64 {
+	return (b.Lat-a.Lat)*(c.Lon-a.Lon) - (b.Lon-a.Lon)*(c.Lat-a.Lat)
+}
+
+// 短形の4辺. 退化した短形では長さ0の辺を含む
+func sides(rectangle Rectangle) []segment {
+	lat, lon := rectangle[0], rectangle[1]
+	corners := []Point{
+		{lat.First, lon.First},
+		{lat.Second, lon.First},
+		{lat.Second, lon.Second},
+		{lat.First, lon.Second},
+	}
+
+	return []segment{
+		{corners[0], corners[1]},
+		{corners[1], corners[2]},
+		{corners[2], corners[3]},
+		{corners[3], corners[0]},
+	}
+}
+
+func center(rectangle Rectangle) Point {
+	return Point{Lat: rectangle[0].center(), Lon: rectangle[1].center()}
+}
+
+func bounds(points []Point) (rectangle Rectangle) {
+	// 点が無ければ外接短形を持たない. NaNの短形は挿入時に ErrInvalidRectangle となる
+	if len(points) == 0 {
+		return Rectangle{&Inteval{First: math.NaN(), Second: math.NaN()}, &Inteval{First: math.NaN(), Second: math.NaN()}}
+	}
+
+	rectangle = Rectangle{
+		&Inteval{First: math.Inf(1), Second: math.Inf(-1)},
+		&Inteval{First: math.Inf(1), Second: math.Inf(-1)},
+	}
+
+	for _, p := range points {
+		rectangle[0].First = min(rectangle[0].First, p.Lat)
+		rectangle[0].Second = max(rectangle[0].Second, p.Lat)
+		rectangle[1].First = min(rectangle[1].First, p.Lon)
+		rectangle[1].Second = max(rectangle[1].Second, p.Lon)
+	}
+
+	return
+}
@@ -0,0 +1,114 @@
+package rtree_test
+
+import (
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeometry(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+	// L字: 緯度5..10, 経度0..5 が欠けている
+	shape := rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {0, 10}, {10, 10}, {10, 5}, {5, 5}, {5, 0}}}
+	// 穴あき: 24..26 の正方形が穴
+	holed := rtree.Polygon{
+		Exterior: rtree.Ring{{20, 20}, {20, 30}, {30, 30}, {30, 20}},
+		Holes:    []rtree.Ring{{{24, 24}, {24, 26}, {26, 26}, {26, 24}}},
+	}
+	line := rtree.LineString{{40, 40}, {50, 50}}
+
+	assert.NoError(t, tree.AddNode(tree.TakePolygon(1, shape)))
+	assert.NoError(t, tree.AddNode(tree.TakePolygon(2, holed)))
+	assert.NoError(t, tree.AddNode(tree.TakeLineString(3, line)))
+	assert.NoError(t, tree.AddNode(tree.TakeRectangle(4, rect(60, 61, 60, 61))))
+
+	search := func(query rtree.Rectangle, opts ...rtree.SearchOption) []uint64 {
+		ids, err := tree.Search(query, opts...)
+		assert.NoError(t, err)
+
+		return ids
+	}
+
+	t.Run("bounds", func(t *testing.T) {
+		assert.Equal(t, rect(0, 10, 0, 10), shape.Bounds())
+		assert.Equal(t, rect(40, 50, 40, 50), line.Bounds())
+	})
+
+	t.Run("intersects", func(t *testing.T) {
+		// 外接短形には含まれるが形状とは交わらない
+		assert.Empty(t, search(rect(7, 8, 1, 2)))
+		assert.Empty(t, search(rect(24.5, 25.5, 24.5, 25.5)))
+		assert.Empty(t, search(rect(41, 42, 44, 45)))
+
+		assert.Equal(t, []uint64{1}, search(rect(4, 6, 1, 2)))
+		assert.Equal(t, []uint64{1}, search(rect(5, 6, 1, 2)), "境界で接する")
+		assert.Equal(t, []uint64{2}, search(rect(25, 27, 25, 27)))
+		assert.Equal(t, []uint64{2}, search(rect(21, 22, 21, 22)), "辺と交わらず内側にある")
+		assert.Equal(t, []uint64{3}, search(rect(44, 46, 45, 47)))
+		assert.Equal(t, []uint64{4}, search(rect(60.5, 70, 60.5, 70)))
+		assert.ElementsMatch(t, []uint64{1, 2, 3, 4}, search(rect(-100, 100, -100, 100)))
+	})
+
+	t.Run("within", func(t *testing.T) {
+		assert.ElementsMatch(t, []uint64{1, 2}, search(rect(-1, 31, -1, 31), rtree.WithMode(rtree.Within)))
+		assert.Empty(t, search(rect(-1, 9, -1, 31), rtree.WithMode(rtree.Within)))
+	})
+
+	t.Run("contains", func(t *testing.T) {
+		contains := func(query rtree.Rectangle) []uint64 {
+			return search(query, rtree.WithMode(rtree.Contains))
+		}
+
+		assert.Equal(t, []uint64{1}, contains(rect(1, 2, 1, 2)))
+		assert.Equal(t, []uint64{1}, contains(rect(4, 6, 6, 7)), "L字の角をまたぐ")
+		assert.Equal(t, []uint64{1}, contains(rect(0, 5, 0, 5)), "境界上も含む")
+		assert.Empty(t, contains(rect(4, 6, 1, 2)))
+		assert.Empty(t, contains(rect(7, 7, 2, 2)))
+
+		assert.Equal(t, []uint64{2}, contains(rect(22, 22, 22, 22)))
+		assert.Equal(t, []uint64{2}, contains(rect(24, 24, 25, 25)), "穴の境界上")
+		assert.Empty(t, contains(rect(25, 25, 25, 25)))
+		assert.Empty(t, contains(rect(23, 27, 23, 27)), "穴を内部に含む")
+		assert.Empty(t, contains(rect(25, 25, 21, 29)), "線分が穴を横切る")
+		assert.Equal(t, []uint64{2}, contains(rect(22, 22, 21, 29)))
+
+		assert.Equal(t, []uint64{3}, contains(rect(45, 45, 45, 45)))
+		assert.Empty(t, contains(rect(45, 45, 46, 46)))
+		assert.Empty(t, contains(rect(45, 46, 45, 46)), "面積を持つ短形")
+	})
+
+	t.Run("update", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		_ = tree.AddNode(tree.TakePolygon(1, shape))
+		ids, _ := tree.Search(rect(7, 8, 1, 2))
+		assert.Empty(t, ids)
+
+		assert.NoError(t, tree.UpdateGeometry(1, holed))
+		ids, _ = tree.Search(rect(21, 22, 21, 22))
+		assert.Equal(t, []uint64{1}, ids)
+		ids, _ = tree.Search(rect(25, 25, 25, 25))
+		assert.Empty(t, ids)
+
+		// 短形へ移動すると形状は外れる
+		assert.NoError(t, tree.Update(1, rect(20, 30, 20, 30)))
+		ids, _ = tree.Search(rect(25, 25, 25, 25))
+		assert.Equal(t, []uint64{1}, ids)
+
+		assert.ErrorIs(t, tree.UpdateGeometry(9, holed), rtree.ErrNotFound)
+	})
+
+	t.Run("empty geometry", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		assert.ErrorIs(t, tree.AddNode(tree.TakeLineString(1, rtree.LineString{})), rtree.ErrInvalidRectangle)
+		assert.ErrorIs(t, tree.AddNode(tree.TakePolygon(2, rtree.Polygon{})), rtree.ErrInvalidRectangle)
+		assert.ErrorIs(t, tree.AddNode(tree.TakeGeometry(3, rtree.MultiPolygon{})), rtree.ErrInvalidRectangle)
+
+		assert.NoError(t, tree.AddNode(tree.TakePolygon(4, shape)))
+		assert.ErrorIs(t, tree.UpdateGeometry(4, rtree.Polygon{}), rtree.ErrInvalidRectangle)
+		assert.NoError(t, tree.Validate())
+	})
+}
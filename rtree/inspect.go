@@ -0,0 +1,139 @@
+package rtree
+
+import (
+	"cmp"
+	"slices"
+)
+
+type (
+	// Stats 木の形状の統計
+	Stats struct {
+		Height  int          // ルートから葉ノードまでの段数. リーフエントリーは含まない
+		Nodes   int          // 中間ノードと葉ノードの数
+		Entries int          // リーフエントリーの数
+		Levels  []LevelStats // 深さ毎の統計. 先頭がルート
+	}
+
+	LevelStats struct {
+		Depth      int
+		Nodes      int
+		Entries    int     // この深さのノードの子の総数
+		FillFactor float64 // Entries / (Nodes * MaxEntrySize)
+		Overlap    float64 // 兄弟ノード間の重なりの面積の総和
+		DeadSpace  float64 // ノードの短形のうち子の短形に覆われない面積の総和
+	}
+)
+
+// Walk 深さ優先(先行順)でノードを辿りfnを呼び出す. リーフエントリーも含む
+// fnがfalseを返したノードの子は辿らない
+func (tree *RTree) Walk(fn func(node *Node, depth int) bool) {
+	tree.Root.walk(0, fn)
+}
+
+func (node *Node) walk(depth int, fn func(node *Node, depth int) bool) {
+	if !fn(node, depth) {
+		return
+	}
+
+	for _, child := range node.Children {
+		child.walk(depth+1, fn)
+	}
+}
+
+// Stats 木の高さ、深さ毎のノード数、充填率、重なりと無駄な面積を集計する
+func (tree *RTree) Stats() (stats Stats) {
+	tree.Walk(func(node *Node, depth int) bool {
+		if node.DataID != nil {
+			stats.Entries++
+			return false
+		}
+
+		if len(stats.Levels) <= depth {
+			stats.Levels = append(stats.Levels, LevelStats{Depth: depth})
+		}
+
+		level := &stats.Levels[depth]
+		level.Nodes++
+		level.Entries += len(node.Children)
+
+		if len(node.Children) == 0 {
+			return false
+		}
+
+		rectangles := make([]Rectangle, len(node.Children))
+		for i, child := range node.Children {
+			rectangles[i] = child.Rectangle
+		}
+
+		level.DeadSpace += max(node.Rectangle.area()-unionArea(rectangles), 0)
+
+		for i, child := range node.Children {
+			for _, sibling := range node.Children[i+1:] {
+				if child.DataID == nil {
+					stats.addOverlap(depth+1, child.Rectangle.overlapArea(sibling.Rectangle))
+				}
+			}
+		}
+
+		return true
+	})
+
+	stats.Height = len(stats.Levels)
+
+	for i := range stats.Levels {
+		level := &stats.Levels[i]
+		stats.Nodes += level.Nodes
+
+		if 0 < tree.cnf.MaxEntrySize {
+			level.FillFactor = float64(level.Entries) / float64(level.Nodes*tree.cnf.MaxEntrySize)
+		}
+	}
+
+	return
+}
+
+// 子ノードの深さの重なりに加算する. 子ノードを辿る前に呼ばれるため、ここで深さを確保する
+// Levels を伸ばすため、呼び出し後は以前に取得した要素へのポインタを使わないこと
+func (stats *Stats) addOverlap(depth int, overlap float64) {
+	for len(stats.Levels) <= depth {
+		stats.Levels = append(stats.Levels, LevelStats{Depth: len(stats.Levels)})
+	}
+
+	stats.Levels[depth].Overlap += overlap
+}
+
+// 短形の和集合の面積. 先頭の次元の区切りで帯に分け、帯毎に残りの次元の和集合を求める
+func unionArea(rectangles []Rectangle) (area float64) {
+	if len(rectangles) == 0 {
+		return 0
+	}
+
+	if len(rectangles[0]) == 0 {
+		return 1
+	}
+
+	var bounds []float64
+
+	for _, rectangle := range rectangles {
+		bounds = append(bounds, rectangle[0].First, rectangle[0].Second)
+	}
+
+	slices.SortFunc(bounds, cmp.Compare[float64])
+	bounds = slices.Compact(bounds)
+
+	for i := 1; i < len(bounds); i++ {
+		var slab []Rectangle
+
+		for _, rectangle := range rectangles {
+			if rectangle[0].First <= bounds[i-1] && bounds[i] <= rectangle[0].Second {
+				slab = append(slab, rectangle[1:])
+			}
+		}
+
+		if 0 < len(slab) {
+			area += (bounds[i] - bounds[i-1]) * unionArea(slab)
+		}
+	}
+
+	return
+}
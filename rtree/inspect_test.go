@@ -0,0 +1,110 @@
+package rtree_test
+
+import (
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalk(t *testing.T) {
+	tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 4}, randomEntries(100, 31))
+	assert.NoError(t, err)
+
+	t.Run("all", func(t *testing.T) {
+		var entries, nodes int
+
+		tree.Walk(func(node *rtree.Node, depth int) bool {
+			if node.DataID != nil {
+				entries++
+			} else {
+				nodes++
+			}
+
+			return true
+		})
+
+		assert.Equal(t, 100, entries)
+		assert.Equal(t, tree.Stats().Nodes, nodes)
+	})
+
+	t.Run("skip children", func(t *testing.T) {
+		var depths []int
+
+		tree.Walk(func(node *rtree.Node, depth int) bool {
+			depths = append(depths, depth)
+			return depth < 1
+		})
+
+		assert.Equal(t, 1+len(tree.Root.Children), len(depths))
+	})
+}
+
+func TestStats(t *testing.T) {
+	t.Run("grid", func(t *testing.T) {
+		// 4x4の格子点. 2x2毎に葉ノードになる
+		var entries []rtree.Entry
+
+		for x := 0; x < 4; x++ {
+			for y := 0; y < 4; y++ {
+				entries = append(entries, rtree.Entry{ID: uint64(x*4 + y), Rectangle: rect(float64(x), float64(x), float64(y), float64(y))})
+			}
+		}
+
+		tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 4}, entries)
+		assert.NoError(t, err)
+
+		assert.Equal(t, rtree.Stats{
+			Height:  2,
+			Nodes:   5,
+			Entries: 16,
+			Levels: []rtree.LevelStats{
+				{Depth: 0, Nodes: 1, Entries: 4, FillFactor: 1, DeadSpace: 9 - 4},
+				{Depth: 1, Nodes: 4, Entries: 16, FillFactor: 1, DeadSpace: 4},
+			},
+		}, tree.Stats())
+	})
+
+	t.Run("overlap", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		for i, r := range []rtree.Rectangle{rect(0, 2, 0, 2), rect(0, 2, 0, 2), rect(1, 3, 1, 3), rect(1, 3, 1, 3)} {
+			_ = tree.AddNode(tree.TakeRectangle(uint64(i), r))
+		}
+
+		stats := tree.Stats()
+		assert.Equal(t, 2, stats.Height)
+		assert.Equal(t, 4, stats.Entries)
+		assert.EqualValues(t, 1, stats.Levels[1].Overlap)
+		assert.EqualValues(t, 9-7, stats.Levels[0].DeadSpace)
+		assert.EqualValues(t, 0, stats.Levels[1].DeadSpace)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		stats := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2}).Stats()
+
+		assert.Equal(t, 1, stats.Height)
+		assert.Equal(t, 1, stats.Nodes)
+		assert.Zero(t, stats.Entries)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 8, Strategy: rtree.RStar})
+
+		for _, e := range randomEntries(1000, 32) {
+			_ = tree.AddNode(tree.TakeRectangle(e.ID, e.Rectangle))
+		}
+
+		stats := tree.Stats()
+		assert.Equal(t, 1000, stats.Entries)
+		assert.Len(t, stats.Levels, stats.Height)
+
+		for _, level := range stats.Levels {
+			assert.LessOrEqual(t, level.FillFactor, 1.0)
+			assert.LessOrEqual(t, 0.0, level.Overlap)
+			assert.LessOrEqual(t, 0.0, level.DeadSpace)
+		}
+
+		assert.Equal(t, 1000, stats.Levels[stats.Height-1].Entries)
+	})
+}
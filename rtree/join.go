@@ -0,0 +1,170 @@
+package rtree
+
+import "math"
+
+type (
+	// JoinPredicate 空間結合の条件
+	JoinPredicate struct {
+		distance bool
+		meters   float64
+	}
+
+	joiner struct {
+		predicate JoinPredicate
+		metric    Metric
+		fn        func(a, b uint64) bool
+	}
+)
+
+// JoinIntersects 短形が重なる組. 形状を持つエントリーは相手の形状 (無ければ短形) と共有点を持つか判定する
+func JoinIntersects() JoinPredicate {
+	return JoinPredicate{}
+}
+
+// JoinWithinDistance 距離がmeters以内の組. 距離は木aのMetricの測地線距離で、Euclideanの場合は大円距離で測る
+// 点同士は地点間の距離、面積を持つエントリーは短形の間の最短距離とする
+func JoinWithinDistance(meters float64) JoinPredicate {
+	return JoinPredicate{distance: true, meters: meters}
+}
+
+// Join 2つの木のノードを同時に辿り、条件を満たすリーフエントリーの組毎にfnを呼び出す. fnがfalseを返したら打ち切る
+// 同じ木同士の結合では同じエントリーの組も含む
+func Join(a, b *RTree, predicate JoinPredicate, fn func(a, b uint64) bool) error {
+	if a.cnf.dimension() != b.cnf.dimension() {
+		return ErrInvalidRectangle
+	}
+
+	j := &joiner{predicate: predicate, metric: a.cnf.Metric, fn: fn}
+
+	if predicate.distance {
+		if a.cnf.dimension() < defaultDimension {
+			return ErrInvalidRectangle
+		}
+
+		if predicate.meters < 0 {
+			return nil
+		}
+
+		if j.metric == Euclidean {
+			j.metric = Haversine
+		}
+	}
+
+	j.pair(a.Root, b.Root)
+
+	return nil
+}
+
+// 条件を満たし得る組を辿る. 打ち切られたらfalseを返す
+func (j *joiner) pair(a, b *Node) bool {
+	switch {
+	case a.DataID != nil && b.DataID != nil:
+		if j.match(a, b) {
+			return j.fn(*a.DataID, *b.DataID)
+		}
+
+		return true
+	case a.DataID != nil:
+		return j.descend(Nodes{a}, b.Children)
+	case b.DataID != nil:
+		return j.descend(a.Children, Nodes{b})
+	default:
+		return j.descend(a.Children, b.Children)
+	}
+}
+
+func (j *joiner) descend(as, bs Nodes) bool {
+	for _, a := range as {
+		for _, b := range bs {
+			if j.near(a.Rectangle, b.Rectangle) && !j.pair(a, b) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// 短形の配下に条件を満たす組が存在し得るか判定
+func (j *joiner) near(a, b Rectangle) bool {
+	if !j.predicate.distance {
+		return a.overlap(b)
+	}
+
+	meters := j.predicate.meters
+	if j.metric == Vincenty {
+		meters /= vincentyLowerBound
+	}
+
+	// aをmeters広げた緯度経度の範囲とbが重なるか
+	delta := meters / earthRadius * 180 / math.Pi
+	minLat, maxLat := a[0].First-delta, a[0].Second+delta
+
+	if b[0].Second < minLat || maxLat < b[0].First {
+		return false
+	}
+
+	// 極を含むなら全経度が対象
+	if maxLat >= 90 || minLat <= -90 {
+		return true
+	}
+
+	// 経度の幅は高緯度ほど広がる
+	lat := math.Max(math.Abs(a[0].First), math.Abs(a[0].Second))
+
+	sinDelta := math.Sin(meters/earthRadius) / math.Cos(toRadian(lat))
+	if sinDelta >= 1 {
+		return true
+	}
+
+	lonDelta := math.Asin(sinDelta) * 180 / math.Pi
+	minLon, maxLon := a[1].First-lonDelta, a[1].Second+lonDelta
+
+	// 経度180度を跨ぐ場合に備えて1周ずらした範囲とも比べる
+	for _, shift := range []float64{-360, 0, 360} {
+		if b[1].First+shift <= maxLon && minLon <= b[1].Second+shift {
+			return true
+		}
+	}
+
+	return false
+}
+
+// リーフエントリーの組が条件を満たすか判定
+func (j *joiner) match(a, b *Node) bool {
+	if !j.predicate.distance {
+		if !a.Rectangle.overlap(b.Rectangle) {
+			return false
+		}
+
+		if a.Geometry != nil && b.Geometry != nil {
+			if intersects, ok := intersectGeometries(a.Geometry, b.Geometry); ok {
+				return intersects
+			}
+		}
+
+		return (a.Geometry == nil || a.Geometry.Intersects(b.Rectangle)) &&
+			(b.Geometry == nil || b.Geometry.Intersects(a.Rectangle))
+	}
+
+	return j.distance(a.Rectangle, b.Rectangle) <= j.predicate.meters
+}
+
+// 短形の間の最短距離. 一方の角から他方の短形までの距離の最小値
+func (j *joiner) distance(a, b Rectangle) (distance float64) {
+	if a.overlap(b) {
+		return 0
+	}
+
+	distance = math.Inf(1)
+
+	for _, pair := range [2][2]Rectangle{{a, b}, {b, a}} {
+		for _, lat := range []float64{pair[0][0].First, pair[0][0].Second} {
+			for _, lon := range []float64{pair[0][1].First, pair[0][1].Second} {
+				distance = math.Min(distance, j.metric.minDistance(pair[1], lat, lon))
+			}
+		}
+	}
+
+	return
+}
@@ -0,0 +1,144 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJoin(t *testing.T) {
+	type pair struct{ a, b uint64 }
+
+	collect := func(t *testing.T, a, b *rtree.RTree, predicate rtree.JoinPredicate) (pairs []pair) {
+		t.Helper()
+
+		assert.NoError(t, rtree.Join(a, b, predicate, func(a, b uint64) bool {
+			pairs = append(pairs, pair{a, b})
+			return true
+		}))
+
+		return
+	}
+
+	t.Run("within distance", func(t *testing.T) {
+		r := rand.New(rand.NewSource(51))
+
+		stores := rtree.NewRTree(&rtree.Config{MaxEntrySize: 8, Metric: rtree.Haversine})
+		stations := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, Strategy: rtree.RStar})
+
+		points := map[*rtree.RTree]map[uint64][2]float64{stores: {}, stations: {}}
+
+		for tree, n := range map[*rtree.RTree]int{stores: 500, stations: 200} {
+			for i := 0; i < n; i++ {
+				p := [2]float64{35.6 + r.Float64()*0.2, 139.6 + r.Float64()*0.3}
+				points[tree][uint64(i)] = p
+				_ = tree.AddNode(tree.TakePlace(uint64(i), p[0], p[1]))
+			}
+		}
+
+		var expected []pair
+
+		for a, p := range points[stores] {
+			for b, q := range points[stations] {
+				if rtree.Haversine.Distance(p[0], p[1], q[0], q[1]) <= 300 {
+					expected = append(expected, pair{a, b})
+				}
+			}
+		}
+
+		assert.NotEmpty(t, expected)
+		assert.ElementsMatch(t, expected, collect(t, stores, stations, rtree.JoinWithinDistance(300)))
+	})
+
+	t.Run("intersects", func(t *testing.T) {
+		a := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		b := rtree.NewRTree(&rtree.Config{MaxEntrySize: 6, Strategy: rtree.Quadratic})
+
+		as, bs := randomEntries(300, 52), randomEntries(400, 53)
+
+		for _, e := range as {
+			_ = a.AddNode(a.TakeRectangle(e.ID, e.Rectangle))
+		}
+
+		for _, e := range bs {
+			_ = b.AddNode(b.TakeRectangle(e.ID, e.Rectangle))
+		}
+
+		var expected []pair
+
+		for _, ea := range as {
+			for _, eb := range bs {
+				if overlaps(ea.Rectangle, eb.Rectangle) {
+					expected = append(expected, pair{ea.ID, eb.ID})
+				}
+			}
+		}
+
+		assert.ElementsMatch(t, expected, collect(t, a, b, rtree.JoinIntersects()))
+
+		// 自己結合は同じエントリーの組を含む
+		pairs := collect(t, a, a, rtree.JoinIntersects())
+		for _, e := range as {
+			assert.Contains(t, pairs, pair{e.ID, e.ID})
+		}
+	})
+
+	t.Run("geometry", func(t *testing.T) {
+		a := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		b := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+
+		_ = a.AddNode(a.TakePolygon(1, rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {0, 10}, {10, 0}}}))
+		_ = b.AddNode(b.TakePlace(1, 1, 1))
+		_ = b.AddNode(b.TakePlace(2, 9, 9))
+
+		assert.Equal(t, []pair{{1, 1}}, collect(t, a, b, rtree.JoinIntersects()))
+	})
+
+	t.Run("geometry pairs", func(t *testing.T) {
+		a := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		b := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+
+		_ = a.AddNode(a.TakePolygon(1, rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {10, 0}, {0, 10}}}))
+
+		// 外接短形は重なるが形状は離れている
+		_ = b.AddNode(b.TakePolygon(2, rtree.Polygon{Exterior: rtree.Ring{{10, 10}, {10, 1.5}, {1.5, 10}}}))
+		// 辺が交わる
+		_ = b.AddNode(b.TakeLineString(3, rtree.LineString{{-1, 5}, {5, 5}}))
+		// 内側に含まれる
+		_ = b.AddNode(b.TakePolygon(4, rtree.Polygon{Exterior: rtree.Ring{{1, 1}, {2, 1}, {1, 2}}}))
+
+		assert.ElementsMatch(t, []pair{{1, 3}, {1, 4}}, collect(t, a, b, rtree.JoinIntersects()))
+		assert.ElementsMatch(t, []pair{{3, 1}, {4, 1}}, collect(t, b, a, rtree.JoinIntersects()))
+	})
+
+	t.Run("antimeridian", func(t *testing.T) {
+		a := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, Metric: rtree.Vincenty})
+		b := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+
+		_ = a.AddNode(a.TakePlace(1, 0, 179.999))
+		_ = b.AddNode(b.TakePlace(2, 0, -179.999))
+		_ = b.AddNode(b.TakePlace(3, 0, -179.99))
+
+		assert.Equal(t, []pair{{1, 2}}, collect(t, a, b, rtree.JoinWithinDistance(300)))
+	})
+
+	t.Run("stop", func(t *testing.T) {
+		tree, _ := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 4}, randomEntries(100, 54))
+
+		count := 0
+		assert.NoError(t, rtree.Join(tree, tree, rtree.JoinIntersects(), func(a, b uint64) bool {
+			count++
+			return count < 5
+		}))
+		assert.Equal(t, 5, count)
+	})
+
+	t.Run("dimension", func(t *testing.T) {
+		a := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		b := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, Dimension: 3})
+
+		assert.ErrorIs(t, rtree.Join(a, b, rtree.JoinIntersects(), func(a, b uint64) bool { return true }), rtree.ErrInvalidRectangle)
+	})
+}
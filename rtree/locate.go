@@ -0,0 +1,46 @@
+package rtree
+
+// Locate 点を含むリーフエントリーのIDを返却する. 多角形は穴を除いて判定し、境界上の点も含む
+// 形状を持たないエントリーは短形で判定する
+func (tree *RTree) Locate(lat, lon float64) (results []uint64, err error) {
+	err = tree.locate(lat, lon, func(entry *Node) bool {
+		results = append(results, *entry.DataID)
+		return true
+	})
+
+	return
+}
+
+// 外接短形で候補を絞り込み、形状で判定する
+func (tree *RTree) locate(lat, lon float64, fn func(entry *Node) bool) error {
+	if tree.cnf.dimension() != defaultDimension {
+		return ErrInvalidRectangle
+	}
+
+	point := Rectangle{
+		&Inteval{First: lat, Second: lat},
+		&Inteval{First: lon, Second: lon},
+	}
+
+	// 点では交差と包含は同じ判定になる
+	tree.Root.search(point, Intersects, fn)
+
+	return nil
+}
+
+// Locate RTree.Locate の値を返す版
+func (t *Tree[T]) Locate(lat, lon float64) (results []T, err error) {
+	err = t.tree.locate(lat, lon, func(entry *Node) bool {
+		results = append(results, t.valueOf(entry))
+		return true
+	})
+
+	return
+}
+
+func (s *SyncRTree) Locate(lat, lon float64) ([]uint64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.tree.Locate(lat, lon)
+}
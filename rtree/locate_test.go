@@ -0,0 +1,124 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocate(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+	// 10x10の区画 id = x*10+y. 隣り合う区画は辺を共有する
+	wards := make(map[uint64]rtree.Polygon)
+
+	for x := 0; x < 10; x++ {
+		for y := 0; y < 10; y++ {
+			lat, lon := float64(x), float64(y)
+			wards[uint64(x*10+y)] = rtree.Polygon{Exterior: rtree.Ring{{lat, lon}, {lat + 1, lon}, {lat + 1, lon + 1}, {lat, lon + 1}}}
+		}
+	}
+
+	// 凹形で穴あきの区域. 時計回りの外周と反時計回りの穴
+	wards[100] = rtree.Polygon{
+		Exterior: rtree.Ring{{2, 2}, {2, 8}, {8, 8}, {8, 6}, {4, 6}, {4, 4}, {8, 4}, {8, 2}, {2, 2}},
+		Holes:    []rtree.Ring{{{2.5, 2.5}, {3.5, 2.5}, {3.5, 3.5}, {2.5, 3.5}}},
+	}
+
+	for id, ward := range wards {
+		assert.NoError(t, tree.AddNode(tree.TakePolygon(id, ward)))
+	}
+
+	t.Run("point in ward", func(t *testing.T) {
+		ids, err := tree.Locate(0.5, 0.5)
+		assert.NoError(t, err)
+		assert.Equal(t, []uint64{0}, ids)
+
+		ids, _ = tree.Locate(2.2, 5.5)
+		assert.ElementsMatch(t, []uint64{25, 100}, ids)
+
+		ids, _ = tree.Locate(6.5, 5.5)
+		assert.Equal(t, []uint64{65}, ids, "凹部")
+
+		ids, _ = tree.Locate(3.2, 3.3)
+		assert.Equal(t, []uint64{33}, ids, "穴")
+
+		ids, _ = tree.Locate(20, 20)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("boundary", func(t *testing.T) {
+		ids, _ := tree.Locate(1, 0.5)
+		assert.ElementsMatch(t, []uint64{0, 10}, ids)
+
+		ids, _ = tree.Locate(1, 1)
+		assert.ElementsMatch(t, []uint64{0, 1, 10, 11}, ids)
+
+		ids, _ = tree.Locate(2.5, 3)
+		assert.ElementsMatch(t, []uint64{22, 23, 100}, ids, "穴の境界")
+	})
+
+	t.Run("brute force", func(t *testing.T) {
+		r := rand.New(rand.NewSource(21))
+
+		for i := 0; i < 2000; i++ {
+			lat, lon := r.Float64()*12-1, r.Float64()*12-1
+
+			var expected []uint64
+
+			for id, ward := range wards {
+				if ward.ContainsPoint(lat, lon) {
+					expected = append(expected, id)
+				}
+			}
+
+			ids, err := tree.Locate(lat, lon)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, expected, ids)
+		}
+	})
+
+	t.Run("dimension", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3, Dimension: 3})
+
+		_, err := tree.Locate(0, 0)
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+	})
+
+	t.Run("value", func(t *testing.T) {
+		tree := rtree.NewTree[string](&rtree.Config{MaxEntrySize: 3})
+
+		_ = tree.InsertGeometry(1, wards[100], "区域")
+		_ = tree.InsertGeometry(2, wards[0], "区画")
+
+		values, err := tree.Locate(5, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"区域"}, values)
+	})
+}
+
+func TestContainsPoint(t *testing.T) {
+	square := rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {0, 1}, {1, 1}, {1, 0}}}
+	// 自己交差する環 (蝶ネクタイ型)
+	bowtie := rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {2, 2}, {2, 0}, {0, 2}}}
+
+	for _, c := range []struct {
+		polygon  rtree.Polygon
+		lat, lon float64
+		expected bool
+	}{
+		{square, 0.5, 0.5, true},
+		{square, 0, 0.5, true},
+		{square, 1, 1, true},
+		{square, 1.5, 0.5, false},
+		{square, 0.5, 1, true},
+		{square, 0.5, -1e-12, false},
+		{bowtie, 0.5, 1, true},
+		{bowtie, 1, 0.5, false},
+		{bowtie, 1, 1, true},
+	} {
+		assert.Equal(t, c.expected, c.polygon.ContainsPoint(c.lat, c.lon), "%v (%v, %v)", c.polygon.Exterior, c.lat, c.lon)
+	}
+}
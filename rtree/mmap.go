@@ -0,0 +1,232 @@
+package rtree
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"errors"
+	"os"
+	"sync"
+)
+
+// ErrClosed Close した MappedTree を探索した
+var ErrClosed = errors.New("rtree: mapped tree is closed")
+
+// MappedTree Save で書き出したファイルをメモリマップして読み取り専用で探索する木
+// ノードを復元せずファイル上のレコードを直接辿るため、複数プロセスで同じファイルを共有できる
+// 形状は Open の際に読み込んでおく. 複数のgoroutineから探索でき、Close は実行中の探索の終了を待つ
+type MappedTree struct {
+	mu         sync.RWMutex
+	cnf        Config
+	data       []byte
+	records    []byte
+	recordSize int
+	geometries map[uint64]Geometry // レコード番号毎の形状
+	unmap      func() error
+	closed     bool
+}
+
+// Open 保存済みの木を読み取り専用で開く
+func Open(path string) (tree *MappedTree, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, unmap, err := mapFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	h, records, geometries, err := decodeFormat(data)
+	if err != nil {
+		_ = unmap()
+		return nil, err
+	}
+
+	return &MappedTree{
+		cnf:        h.cnf,
+		data:       data,
+		records:    records,
+		recordSize: recordSize(h.dimension),
+		geometries: geometries,
+		unmap:      unmap,
+	}, nil
+}
+
+// Close メモリマップを解放する. 以降の探索は ErrClosed を返す
+func (tree *MappedTree) Close() (err error) {
+	tree.mu.Lock()
+	defer tree.mu.Unlock()
+
+	if tree.closed {
+		return nil
+	}
+
+	err = tree.unmap()
+	tree.data, tree.records, tree.geometries, tree.unmap = nil, nil, nil, nil
+	tree.closed = true
+
+	return
+}
+
+// Search RTree.Search と同じ
+func (tree *MappedTree) Search(rectangle Rectangle, opts ...SearchOption) (results []uint64, err error) {
+	err = tree.SearchFunc(rectangle, func(id uint64) bool {
+		results = append(results, id)
+		return true
+	}, opts...)
+
+	return
+}
+
+// SearchFunc RTree.SearchFunc と同じ. fnの中で Close を呼ぶと終わらない
+func (tree *MappedTree) SearchFunc(rectangle Rectangle, fn func(id uint64) bool, opts ...SearchOption) (err error) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	if tree.closed {
+		return ErrClosed
+	}
+
+	if !tree.cnf.validRectangle(rectangle) {
+		return ErrInvalidRectangle
+	}
+
+	o := newSearchOption(opts)
+	parts := tree.cnf.Metric.splitAntimeridian(rectangle)
+	found := make(map[uint64]bool)
+	buf := zeroRectangle(tree.cnf.dimension())
+	count := 0
+
+	for _, part := range parts {
+		next := tree.search(0, part, o.mode, buf, func(index uint64) bool {
+			if 1 < len(parts) {
+				if found[index] {
+					return true
+				}
+
+				// 包含判定は分割した全ての短形を包含する必要がある
+				if o.mode == Contains {
+					for _, other := range parts {
+						if !o.mode.matchGeometry(buf, tree.geometries[index], other) {
+							return true
+						}
+					}
+				}
+
+				found[index] = true
+			}
+
+			count++
+
+			if !fn(tree.id(index)) {
+				return false
+			}
+
+			return o.limit <= 0 || count < o.limit
+		})
+
+		if !next {
+			break
+		}
+	}
+
+	return
+}
+
+// Nearest RTree.Nearest と同じ
+func (tree *MappedTree) Nearest(lat, lon float64, k int, opts ...NearestOption) (results []Neighbor, err error) {
+	tree.mu.RLock()
+	defer tree.mu.RUnlock()
+
+	if tree.closed {
+		return nil, ErrClosed
+	}
+
+	if tree.cnf.dimension() < defaultDimension {
+		return nil, ErrInvalidRectangle
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	o := newNearestOption(opts)
+	buf := zeroRectangle(tree.cnf.dimension())
+
+	queue := &nearestQueue{}
+	heap.Push(queue, nearestItem{index: 0})
+
+	for 0 < queue.Len() && len(results) < k {
+		item := heap.Pop(queue).(nearestItem) //nolint:forcetypeassert
+
+		// 以降のエントリーは全て上限より遠い
+		if o.bounded && o.maxDistance < item.distance {
+			break
+		}
+
+		if tree.isEntry(item.index) {
+			results = append(results, Neighbor{ID: tree.id(item.index), Distance: item.distance})
+			continue
+		}
+
+		first, count := tree.children(item.index)
+
+		for child := first; child < first+count; child++ {
+			readRectangle(tree.record(child), buf)
+			heap.Push(queue, nearestItem{index: child, distance: tree.cnf.Metric.minDistance(buf, lat, lon)})
+		}
+	}
+
+	return
+}
+
+// 該当するエントリーのレコード番号毎にfnを呼び出す. fnの呼び出し時点でbufはエントリーの短形を保持する
+func (tree *MappedTree) search(index uint64, rectangle Rectangle, mode SearchMode, buf Rectangle, fn func(index uint64) bool) bool {
+	first, count := tree.children(index)
+
+	for child := first; child < first+count; child++ {
+		readRectangle(tree.record(child), buf)
+
+		if tree.isEntry(child) {
+			if mode.matchGeometry(buf, tree.geometries[child], rectangle) && !fn(child) {
+				return false
+			}
+
+			continue
+		}
+
+		if mode.prune(buf, rectangle) {
+			continue
+		}
+
+		if !tree.search(child, rectangle, mode, buf, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (tree *MappedTree) record(index uint64) []byte {
+	return tree.records[index*uint64(tree.recordSize):]
+}
+
+func (tree *MappedTree) isEntry(index uint64) bool {
+	return binary.LittleEndian.Uint32(tree.record(index)) == kindEntry
+}
+
+func (tree *MappedTree) id(index uint64) uint64 {
+	return binary.LittleEndian.Uint64(tree.record(index)[8:])
+}
+
+// 子レコードの先頭番号と数
+func (tree *MappedTree) children(index uint64) (first, count uint64) {
+	rec := tree.record(index)
+	if binary.LittleEndian.Uint32(rec) == kindEntry {
+		return 0, 0
+	}
+
+	return binary.LittleEndian.Uint64(rec[8:]), uint64(binary.LittleEndian.Uint32(rec[4:]))
+}
@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+package rtree
+
+import (
+	"io"
+	"os"
+)
+
+// メモリマップが使えない環境ではファイル全体を読み込む
+func mapFile(file *os.File) (data []byte, unmap func() error, err error) {
+	data, err = io.ReadAll(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, func() error { return nil }, nil
+}
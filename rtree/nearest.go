@@ -0,0 +1,158 @@
+package rtree
+
+import (
+	"container/heap"
+	"math"
+)
+
+type (
+	// Neighbor 近傍探索の結果
+	Neighbor struct {
+		ID       uint64
+		Distance float64
+	}
+
+	nearestItem struct {
+		node     *Node
+		index    uint64 // MappedTree のレコード番号
+		distance float64
+	}
+
+	// 距離の昇順に取り出す優先度付きキュー
+	nearestQueue []nearestItem
+
+	NearestOption func(*nearestOption)
+
+	nearestOption struct {
+		maxDistance float64
+		bounded     bool // maxDistanceが指定されたか
+	}
+)
+
+// WithMaxDistance 近傍探索で距離がdを超えるエントリーを除外する. 0なら距離0のエントリーのみ
+func WithMaxDistance(d float64) NearestOption {
+	return func(o *nearestOption) {
+		o.maxDistance = d
+		o.bounded = true
+	}
+}
+
+func newNearestOption(opts []NearestOption) (o *nearestOption) {
+	o = new(nearestOption)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return
+}
+
+// Nearest 地点に近いk件のエントリーを距離の昇順に返却する. 距離はConfig.Metricに従う
+// 3次元以上の木では先頭の2次元を緯度経度とみなして測る. 1次元の木では ErrInvalidRectangle を返す
+// 短形までの最小距離で枝刈りする最良優先探索 (branch and bound)
+func (tree *RTree) Nearest(lat, lon float64, k int, opts ...NearestOption) (results []Neighbor, err error) {
+	if tree.cnf.dimension() < defaultDimension {
+		return nil, ErrInvalidRectangle
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	metric := tree.cnf.Metric
+
+	return neighbors(tree.nearest(k, newNearestOption(opts), func(rectangle Rectangle) float64 {
+		return metric.minDistance(rectangle, lat, lon)
+	})), nil
+}
+
+// NearestPoint 多次元の点に近いk件のエントリーを全次元のユークリッド距離の昇順に返却する
+func (tree *RTree) NearestPoint(point []float64, k int, opts ...NearestOption) (results []Neighbor, err error) {
+	if len(point) != tree.cnf.dimension() {
+		return nil, ErrInvalidRectangle
+	}
+
+	if k <= 0 {
+		return nil, nil
+	}
+
+	target := make(Rectangle, len(point))
+	for i, p := range point {
+		target[i] = &Inteval{First: p, Second: p}
+	}
+
+	return neighbors(tree.nearest(k, newNearestOption(opts), func(rectangle Rectangle) float64 {
+		return rectangle.minDistance(target)
+	})), nil
+}
+
+// 短形までの距離distanceによる最良優先探索. 近いリーフエントリーを返す
+func (tree *RTree) nearest(k int, o *nearestOption, distance func(Rectangle) float64) (results []nearestItem) {
+	queue := &nearestQueue{}
+	heap.Push(queue, nearestItem{node: tree.Root})
+
+	for 0 < queue.Len() && len(results) < k {
+		item := heap.Pop(queue).(nearestItem) //nolint:forcetypeassert
+
+		// 以降のエントリーは全て上限より遠い
+		if o.bounded && o.maxDistance < item.distance {
+			break
+		}
+
+		if item.node.DataID != nil {
+			results = append(results, item)
+			continue
+		}
+
+		for _, child := range item.node.Children {
+			heap.Push(queue, nearestItem{node: child, distance: distance(child.Rectangle)})
+		}
+	}
+
+	return
+}
+
+func neighbors(items []nearestItem) (results []Neighbor) {
+	for _, item := range items {
+		results = append(results, Neighbor{ID: *item.node.DataID, Distance: item.distance})
+	}
+
+	return
+}
+
+// 短形と点の最小距離. 点が短形内にあれば0. 点の次元が少ない場合は点の次元のみで測る
+func (rectangle Rectangle) minDistance(point Rectangle) (distance float64) {
+	for i := range point {
+		var d float64
+
+		switch p := point[i].First; {
+		case p < rectangle[i].First:
+			d = rectangle[i].First - p
+		case rectangle[i].Second < p:
+			d = p - rectangle[i].Second
+		}
+
+		distance += d * d
+	}
+
+	return math.Sqrt(distance)
+}
+
+func (queue nearestQueue) Len() int { return len(queue) }
+
+func (queue nearestQueue) Less(i, j int) bool { return queue[i].distance < queue[j].distance }
+
+func (queue nearestQueue) Swap(i, j int) { queue[i], queue[j] = queue[j], queue[i] }
+
+func (queue *nearestQueue) Push(x any) {
+	*queue = append(*queue, x.(nearestItem)) //nolint:forcetypeassert
+}
+
+func (queue *nearestQueue) Pop() any {
+	old := *queue
+	n := len(old)
+	item := old[n-1]
+	*queue = old[:n-1]
+
+	return item
+}
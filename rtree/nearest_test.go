@@ -0,0 +1,83 @@
+package rtree_test
+
+import (
+	"math"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNearest(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+	// 5x5の格子点 id = x*10+y
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 5; y++ {
+			_ = tree.AddNode(tree.TakePlace(uint64(x*10+y), float64(x), float64(y)))
+		}
+	}
+
+	t.Run("nearest k", func(t *testing.T) {
+		results, err := tree.Nearest(2.1, 2.2, 3)
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+
+		assert.EqualValues(t, 22, results[0].ID)
+		assert.InDelta(t, math.Hypot(0.1, 0.2), results[0].Distance, 1e-9)
+		assert.EqualValues(t, 23, results[1].ID)
+		assert.EqualValues(t, 32, results[2].ID)
+	})
+
+	t.Run("ascending", func(t *testing.T) {
+		results, err := tree.Nearest(-1, -1, 25)
+		assert.NoError(t, err)
+		assert.Len(t, results, 25)
+		assert.EqualValues(t, 0, results[0].ID)
+		assert.EqualValues(t, 44, results[24].ID)
+
+		for i := 1; i < len(results); i++ {
+			assert.LessOrEqual(t, results[i-1].Distance, results[i].Distance)
+		}
+	})
+
+	t.Run("max distance", func(t *testing.T) {
+		results, err := tree.Nearest(0, 0, 10, rtree.WithMaxDistance(1))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []rtree.Neighbor{
+			{ID: 0, Distance: 0},
+			{ID: 1, Distance: 1},
+			{ID: 10, Distance: 1},
+		}, results)
+	})
+
+	t.Run("max distance zero", func(t *testing.T) {
+		results, err := tree.Nearest(0, 0, 10, rtree.WithMaxDistance(0))
+		assert.NoError(t, err)
+		assert.Equal(t, []rtree.Neighbor{{ID: 0, Distance: 0}}, results)
+	})
+
+	t.Run("one dimension", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3, Dimension: 1})
+
+		for i := 0; i < 10; i++ {
+			_ = tree.AddNode(tree.TakePoint(uint64(i), float64(i)))
+		}
+
+		_, err := tree.Nearest(0, 0, 3)
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+		_, err = tree.WithinRadius(0, 0, 1000)
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+		results, err := tree.NearestPoint([]float64{4.25}, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, []rtree.Neighbor{{ID: 4, Distance: 0.25}, {ID: 5, Distance: 0.75}}, results)
+	})
+
+	t.Run("empty tree", func(t *testing.T) {
+		results, err := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3}).Nearest(0, 0, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+	})
+}
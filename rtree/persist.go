@@ -0,0 +1,449 @@
+package rtree
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+// 保存形式 (リトルエンディアン)
+//
+//	header  : magic "RTRE", version, dimension, MaxEntrySize, MinEntrySize, Metric, Strategy, Packing, レコード数
+//	records : 幅優先順のノード. 先頭がルート. 兄弟ノードは連続して並ぶ
+//	          kind(uint32) 子の数(uint32) 先頭の子の番号またはDataID(uint64) 短形(float64 x 2 x 次元数)
+//	geometries: 形状を持つリーフエントリー毎に レコード番号(uint64) 種類(uint32) 形状. version 2 から
+//	          点列は 点の数(uint32) 緯度経度(float64 x 2 x 点の数)、多角形は 外周と穴の数(uint32) 点列
+//	trailer : header, records, geometries の CRC-32C
+const (
+	formatVersion = 2
+
+	headerSize  = 32
+	trailerSize = 4
+
+	recordHeaderSize = 16
+
+	kindNode  = 0
+	kindEntry = 1
+
+	geometryLineString   = 1
+	geometryPolygon      = 2
+	geometryMultiPolygon = 3
+)
+
+//nolint:gochecknoglobals
+var (
+	formatMagic = [4]byte{'R', 'T', 'R', 'E'}
+	crcTable    = crc32.MakeTable(crc32.Castagnoli)
+)
+
+var (
+	ErrInvalidFormat      = errors.New("rtree: invalid format")
+	ErrUnsupportedVersion = errors.New("rtree: unsupported format version")
+	ErrChecksum           = errors.New("rtree: checksum mismatch")
+	// ErrUnsupportedGeometry 保存できない形状. 保存できるのは LineString, Polygon, MultiPolygon
+	ErrUnsupportedGeometry = errors.New("rtree: unsupported geometry")
+)
+
+type (
+	header struct {
+		version     int
+		dimension   int
+		cnf         Config
+		recordCount uint64
+	}
+
+	// 形状の読み取り. 範囲外を読もうとしたらerrを設定し、以降はゼロ値を返す
+	geometryReader struct {
+		data []byte
+		err  error
+	}
+)
+
+func recordSize(dimension int) int {
+	return recordHeaderSize + 16*dimension
+}
+
+// Save 木をバイナリ形式で書き出す. 形状も保存する
+// 保存できない形状を持つエントリーがあれば ErrUnsupportedGeometry を返し、何も書き出さない
+func (tree *RTree) Save(w io.Writer) (err error) {
+	dimension := tree.cnf.dimension()
+
+	// 幅優先で番号を振る
+	nodes := Nodes{tree.Root}
+	for i := 0; i < len(nodes); i++ {
+		nodes = append(nodes, nodes[i].Children...)
+	}
+
+	var geometries []byte
+
+	for i, node := range nodes {
+		if node.Geometry != nil {
+			if geometries, err = appendGeometry(geometries, uint64(i), node.Geometry); err != nil {
+				return err
+			}
+		}
+	}
+
+	hash := crc32.New(crcTable)
+	buf := bufio.NewWriter(io.MultiWriter(w, hash))
+
+	h := header{version: formatVersion, dimension: dimension, cnf: *tree.cnf, recordCount: uint64(len(nodes))}
+	if _, err = buf.Write(h.encode()); err != nil {
+		return err
+	}
+
+	record := make([]byte, recordSize(dimension))
+	next := uint64(1)
+
+	for _, node := range nodes {
+		if node.DataID != nil {
+			binary.LittleEndian.PutUint32(record[0:], kindEntry)
+			binary.LittleEndian.PutUint32(record[4:], 0)
+			binary.LittleEndian.PutUint64(record[8:], *node.DataID)
+		} else {
+			binary.LittleEndian.PutUint32(record[0:], kindNode)
+			binary.LittleEndian.PutUint32(record[4:], uint32(len(node.Children)))
+			binary.LittleEndian.PutUint64(record[8:], next)
+			next += uint64(len(node.Children))
+		}
+
+		for dim := 0; dim < dimension; dim++ {
+			binary.LittleEndian.PutUint64(record[recordHeaderSize+16*dim:], math.Float64bits(node.Rectangle[dim].First))
+			binary.LittleEndian.PutUint64(record[recordHeaderSize+16*dim+8:], math.Float64bits(node.Rectangle[dim].Second))
+		}
+
+		if _, err = buf.Write(record); err != nil {
+			return err
+		}
+	}
+
+	if _, err = buf.Write(geometries); err != nil {
+		return err
+	}
+
+	if err = buf.Flush(); err != nil {
+		return err
+	}
+
+	return binary.Write(w, binary.LittleEndian, hash.Sum32())
+}
+
+// Load Save で書き出した木を読み込む
+func Load(r io.Reader) (tree *RTree, err error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	h, records, geometries, err := decodeFormat(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cnf := h.cnf
+	tree = NewRTree(&cnf)
+
+	nodes := make(Nodes, h.recordCount)
+	size := recordSize(h.dimension)
+
+	for i := range nodes {
+		rec := records[i*size : (i+1)*size]
+
+		node := tree.NewNode(nil)
+		readRectangle(rec, node.Rectangle)
+
+		if binary.LittleEndian.Uint32(rec) == kindEntry {
+			id := binary.LittleEndian.Uint64(rec[8:])
+			node.DataID = &id
+			node.Geometry = geometries[uint64(i)]
+			tree.entries[id] = node
+		}
+
+		nodes[i] = node
+	}
+
+	for i, node := range nodes {
+		rec := records[i*size:]
+		count := binary.LittleEndian.Uint32(rec[4:])
+		first := binary.LittleEndian.Uint64(rec[8:])
+
+		for j := uint64(0); j < uint64(count); j++ {
+			node.AddEntry(nodes[first+j])
+		}
+	}
+
+	tree.Root = nodes[0]
+
+	return
+}
+
+// ヘッダーとチェックサムを検証し、レコード部分とレコード番号毎の形状を返す
+func decodeFormat(data []byte) (h header, records []byte, geometries map[uint64]Geometry, err error) {
+	if len(data) < headerSize+trailerSize {
+		return h, nil, nil, ErrInvalidFormat
+	}
+
+	body := data[:len(data)-trailerSize]
+	if crc32.Checksum(body, crcTable) != binary.LittleEndian.Uint32(data[len(body):]) {
+		return h, nil, nil, ErrChecksum
+	}
+
+	if h, err = decodeHeader(body[:headerSize]); err != nil {
+		return h, nil, nil, err
+	}
+
+	records = body[headerSize:]
+	size := uint64(recordSize(h.dimension))
+
+	if h.recordCount == 0 || uint64(len(records))/size < h.recordCount {
+		return h, nil, nil, ErrInvalidFormat
+	}
+
+	rest := records[h.recordCount*size:]
+	records = records[:h.recordCount*size]
+
+	// version 1 は形状を持たない
+	if h.version == 1 && len(rest) != 0 {
+		return h, nil, nil, ErrInvalidFormat
+	}
+
+	// 子の番号が範囲内で、ルート以外の全レコードが一度ずつ参照されること
+	next := uint64(1)
+
+	for i := uint64(0); i < h.recordCount; i++ {
+		rec := records[i*size:]
+
+		switch binary.LittleEndian.Uint32(rec) {
+		case kindEntry:
+		case kindNode:
+			count := uint64(binary.LittleEndian.Uint32(rec[4:]))
+			if binary.LittleEndian.Uint64(rec[8:]) != next || h.recordCount < next+count {
+				return h, nil, nil, ErrInvalidFormat
+			}
+
+			next += count
+		default:
+			return h, nil, nil, ErrInvalidFormat
+		}
+	}
+
+	if next != h.recordCount {
+		return h, nil, nil, ErrInvalidFormat
+	}
+
+	if geometries, err = decodeGeometries(rest, records, size); err != nil {
+		return h, nil, nil, err
+	}
+
+	return h, records, geometries, nil
+}
+
+func (h header) encode() []byte {
+	b := make([]byte, headerSize)
+
+	copy(b, formatMagic[:])
+	binary.LittleEndian.PutUint16(b[4:], formatVersion)
+	binary.LittleEndian.PutUint16(b[6:], uint16(h.dimension))
+	binary.LittleEndian.PutUint32(b[8:], uint32(h.cnf.MaxEntrySize))
+	binary.LittleEndian.PutUint32(b[12:], uint32(h.cnf.MinEntrySize))
+	b[16] = byte(h.cnf.Metric)
+	b[17] = byte(h.cnf.Strategy)
+	b[18] = byte(h.cnf.Packing)
+	binary.LittleEndian.PutUint64(b[20:], h.recordCount)
+
+	return b
+}
+
+func decodeHeader(b []byte) (h header, err error) {
+	if [4]byte(b[:4]) != formatMagic {
+		return h, ErrInvalidFormat
+	}
+
+	h.version = int(binary.LittleEndian.Uint16(b[4:]))
+	if h.version == 0 || formatVersion < h.version {
+		return h, ErrUnsupportedVersion
+	}
+
+	h.dimension = int(binary.LittleEndian.Uint16(b[6:]))
+	if h.dimension == 0 {
+		return h, ErrInvalidFormat
+	}
+
+	h.cnf = Config{
+		MaxEntrySize: int(binary.LittleEndian.Uint32(b[8:])),
+		MinEntrySize: int(binary.LittleEndian.Uint32(b[12:])),
+		Metric:       Metric(b[16]),
+		Strategy:     Strategy(b[17]),
+		Packing:      Packing(b[18]),
+		Dimension:    h.dimension,
+	}
+	h.recordCount = binary.LittleEndian.Uint64(b[20:])
+
+	return
+}
+
+// レコードの短形をrectangleへ書き込む
+func readRectangle(rec []byte, rectangle Rectangle) {
+	for dim := range rectangle {
+		rectangle[dim].First = math.Float64frombits(binary.LittleEndian.Uint64(rec[recordHeaderSize+16*dim:]))
+		rectangle[dim].Second = math.Float64frombits(binary.LittleEndian.Uint64(rec[recordHeaderSize+16*dim+8:]))
+	}
+}
+
+// 形状をレコード番号と共に追加する
+func appendGeometry(b []byte, index uint64, geometry Geometry) ([]byte, error) {
+	b = binary.LittleEndian.AppendUint64(b, index)
+
+	switch g := geometry.(type) {
+	case LineString:
+		b = binary.LittleEndian.AppendUint32(b, geometryLineString)
+		b = appendPoints(b, g)
+	case Polygon:
+		b = binary.LittleEndian.AppendUint32(b, geometryPolygon)
+		b = appendPolygon(b, g)
+	case MultiPolygon:
+		b = binary.LittleEndian.AppendUint32(b, geometryMultiPolygon)
+		b = binary.LittleEndian.AppendUint32(b, uint32(len(g)))
+
+		for _, polygon := range g {
+			b = appendPolygon(b, polygon)
+		}
+	default:
+		return nil, ErrUnsupportedGeometry
+	}
+
+	return b, nil
+}
+
+func appendPolygon(b []byte, polygon Polygon) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(1+len(polygon.Holes)))
+	b = appendPoints(b, polygon.Exterior)
+
+	for _, hole := range polygon.Holes {
+		b = appendPoints(b, hole)
+	}
+
+	return b
+}
+
+func appendPoints(b []byte, points []Point) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(points)))
+
+	for _, p := range points {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(p.Lat))
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(p.Lon))
+	}
+
+	return b
+}
+
+// 形状部分を読み込む. 参照先はリーフエントリーのレコードで、同じレコードは一度だけ現れること
+func decodeGeometries(data, records []byte, size uint64) (geometries map[uint64]Geometry, err error) {
+	geometries = make(map[uint64]Geometry)
+	r := &geometryReader{data: data}
+
+	for len(r.data) != 0 && r.err == nil {
+		index := r.uint64()
+		if uint64(len(records))/size <= index || binary.LittleEndian.Uint32(records[index*size:]) != kindEntry {
+			return nil, ErrInvalidFormat
+		}
+
+		if _, ok := geometries[index]; ok {
+			return nil, ErrInvalidFormat
+		}
+
+		switch r.uint32() {
+		case geometryLineString:
+			geometries[index] = LineString(r.points())
+		case geometryPolygon:
+			geometries[index] = r.polygon()
+		case geometryMultiPolygon:
+			multi := make(MultiPolygon, r.count(4))
+			if len(multi) == 0 {
+				return nil, ErrInvalidFormat
+			}
+
+			for i := range multi {
+				multi[i] = r.polygon()
+			}
+
+			geometries[index] = multi
+		default:
+			return nil, ErrInvalidFormat
+		}
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return
+}
+
+func (r *geometryReader) polygon() (polygon Polygon) {
+	rings := r.count(4)
+	if rings == 0 {
+		r.err = ErrInvalidFormat
+		return
+	}
+
+	polygon.Exterior = r.points()
+
+	for i := 1; i < rings; i++ {
+		polygon.Holes = append(polygon.Holes, r.points())
+	}
+
+	return
+}
+
+// 点列. 点の無い点列は外接短形を持たないため不正とする
+func (r *geometryReader) points() (points []Point) {
+	count := r.count(16)
+	if count == 0 {
+		r.err = ErrInvalidFormat
+		return nil
+	}
+
+	points = make([]Point, count)
+	for i := range points {
+		points[i] = Point{Lat: math.Float64frombits(r.uint64()), Lon: math.Float64frombits(r.uint64())}
+	}
+
+	return
+}
+
+// 要素数. 1要素が少なくともsizeバイトとして、残りに収まらなければ不正とする
+func (r *geometryReader) count(size int) int {
+	count := int(r.uint32())
+	if len(r.data)/size < count {
+		r.err = ErrInvalidFormat
+		return 0
+	}
+
+	return count
+}
+
+func (r *geometryReader) uint32() (v uint32) {
+	if len(r.data) < 4 {
+		r.err = ErrInvalidFormat
+		return 0
+	}
+
+	v, r.data = binary.LittleEndian.Uint32(r.data), r.data[4:]
+
+	return
+}
+
+func (r *geometryReader) uint64() (v uint64) {
+	if len(r.data) < 8 {
+		r.err = ErrInvalidFormat
+		return 0
+	}
+
+	v, r.data = binary.LittleEndian.Uint64(r.data), r.data[8:]
+
+	return
+}
@@ -0,0 +1,222 @@
+package rtree_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"rtree"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveLoad(t *testing.T) {
+	entries := randomEntries(1000, 11)
+	tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8, Metric: rtree.Haversine}, entries)
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.Save(&buf))
+
+	t.Run("load", func(t *testing.T) {
+		loaded, err := rtree.Load(bytes.NewReader(buf.Bytes()))
+		assert.NoError(t, err)
+
+		query := rtree.NewBoundingBox(-20, -40, 40, 60)
+
+		want, _ := tree.Search(query)
+		got, err := loaded.Search(query)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, want, got)
+
+		wantNearest, _ := tree.Nearest(35, 139, 5)
+		gotNearest, err := loaded.Nearest(35, 139, 5)
+		assert.NoError(t, err)
+		assert.Equal(t, wantNearest, gotNearest)
+
+		// 読み込んだ木も更新できる
+		assert.NoError(t, loaded.Delete(entries[0].ID))
+		assert.ErrorIs(t, loaded.AddNode(loaded.TakePlace(entries[1].ID, 0, 0)), rtree.ErrDuplicateID)
+		assert.NoError(t, loaded.AddNode(loaded.TakePlace(5000, 0, 0)))
+		assert.Len(t, collectIDs(loaded.Root), 1000)
+	})
+
+	t.Run("geometry", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+		// L字: 緯度5..10, 経度0..5 が欠けている
+		shape := rtree.Polygon{Exterior: rtree.Ring{{0, 0}, {0, 10}, {10, 10}, {10, 5}, {5, 5}, {5, 0}}}
+		holed := rtree.MultiPolygon{{
+			Exterior: rtree.Ring{{20, 20}, {20, 30}, {30, 30}, {30, 20}},
+			Holes:    []rtree.Ring{{{24, 24}, {24, 26}, {26, 26}, {26, 24}}},
+		}}
+		line := rtree.LineString{{40, 40}, {50, 50}}
+
+		assert.NoError(t, tree.AddNode(tree.TakePolygon(1, shape)))
+		assert.NoError(t, tree.AddNode(tree.TakeGeometry(2, holed)))
+		assert.NoError(t, tree.AddNode(tree.TakeLineString(3, line)))
+		assert.NoError(t, tree.AddNode(tree.TakePlace(4, 7, 1)))
+
+		var saved bytes.Buffer
+		assert.NoError(t, tree.Save(&saved))
+
+		path := filepath.Join(t.TempDir(), "geometry.rtree")
+		assert.NoError(t, os.WriteFile(path, saved.Bytes(), 0o600))
+
+		loaded, err := rtree.Load(bytes.NewReader(saved.Bytes()))
+		assert.NoError(t, err)
+
+		mapped, err := rtree.Open(path)
+		assert.NoError(t, err)
+
+		defer func() { assert.NoError(t, mapped.Close()) }()
+
+		for _, query := range []rtree.Rectangle{
+			rect(7, 8, 1, 2),     // L字の欠けた部分
+			rect(25, 25, 25, 25), // 穴の中
+			rect(45, 46, 40, 41), // 線から外れた部分
+			rect(21, 22, 21, 22),
+			rect(0, 60, 0, 60),
+		} {
+			want, _ := tree.Search(query)
+			got, err := loaded.Search(query)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, want, got, query)
+
+			got, err = mapped.Search(query)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, want, got, query)
+		}
+
+		ids, _ := loaded.Search(rect(7, 8, 1, 2))
+		assert.Equal(t, []uint64{4}, ids)
+
+		ids, _ = mapped.Search(rect(2, 2, 2, 2), rtree.WithMode(rtree.Contains))
+		assert.Equal(t, []uint64{1}, ids)
+	})
+
+	t.Run("unsupported geometry", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+		assert.NoError(t, tree.AddNode(tree.TakeGeometry(1, circle{})))
+
+		var out bytes.Buffer
+		assert.ErrorIs(t, tree.Save(&out), rtree.ErrUnsupportedGeometry)
+		assert.Zero(t, out.Len())
+	})
+
+	t.Run("empty tree", func(t *testing.T) {
+		var empty bytes.Buffer
+		assert.NoError(t, rtree.NewRTree(&rtree.Config{MaxEntrySize: 4}).Save(&empty))
+
+		loaded, err := rtree.Load(&empty)
+		assert.NoError(t, err)
+		assert.Empty(t, loaded.Root.Children)
+		assert.NoError(t, loaded.AddNode(loaded.TakePlace(1, 1, 1)))
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		data := bytes.Clone(buf.Bytes())
+		data[100] ^= 0xff
+
+		_, err := rtree.Load(bytes.NewReader(data))
+		assert.ErrorIs(t, err, rtree.ErrChecksum)
+
+		_, err = rtree.Load(bytes.NewReader(data[:10]))
+		assert.ErrorIs(t, err, rtree.ErrInvalidFormat)
+
+		_, err = rtree.Load(bytes.NewReader([]byte("not an rtree at all, really not")))
+		assert.Error(t, err)
+	})
+}
+
+func TestOpen(t *testing.T) {
+	entries := randomEntries(1000, 12)
+	tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 8, Metric: rtree.Haversine}, entries)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "index.rtree")
+
+	file, err := os.Create(path)
+	assert.NoError(t, err)
+	assert.NoError(t, tree.Save(file))
+	assert.NoError(t, file.Close())
+
+	mapped, err := rtree.Open(path)
+	assert.NoError(t, err)
+
+	defer func() { assert.NoError(t, mapped.Close()) }()
+
+	for _, query := range []rtree.Rectangle{
+		rtree.NewBoundingBox(-20, -40, 40, 60),
+		rtree.NewBoundingBox(-60, 150, 60, -150), // 経度180度を跨ぐ
+	} {
+		want, _ := tree.Search(query)
+		got, err := mapped.Search(query)
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, want, got)
+	}
+
+	ids, err := mapped.Search(rtree.NewBoundingBox(-90, -180, 90, 180), rtree.WithLimit(10))
+	assert.NoError(t, err)
+	assert.Len(t, ids, 10)
+
+	want, _ := tree.Nearest(-33.8, 151.2, 5)
+	got, err := mapped.Nearest(-33.8, 151.2, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	_, err = mapped.Search(rtree.Rectangle{&rtree.Inteval{}})
+	assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+	_, err = mapped.Search(rtree.Rectangle{nil, nil})
+	assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+	_, err = rtree.Open(filepath.Join(t.TempDir(), "missing"))
+	assert.Error(t, err)
+
+	t.Run("closed", func(t *testing.T) {
+		closed, err := rtree.Open(path)
+		assert.NoError(t, err)
+		assert.NoError(t, closed.Close())
+		assert.NoError(t, closed.Close())
+
+		_, err = closed.Search(rtree.NewBoundingBox(-90, -180, 90, 180))
+		assert.ErrorIs(t, err, rtree.ErrClosed)
+
+		_, err = closed.Nearest(-33.8, 151.2, 5)
+		assert.ErrorIs(t, err, rtree.ErrClosed)
+	})
+
+	t.Run("close during search", func(t *testing.T) {
+		closing, err := rtree.Open(path)
+		assert.NoError(t, err)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for j := 0; j < 100; j++ {
+					if _, err := closing.Search(rtree.NewBoundingBox(-90, -180, 90, 180)); err != nil {
+						assert.ErrorIs(t, err, rtree.ErrClosed)
+						return
+					}
+				}
+			}()
+		}
+
+		assert.NoError(t, closing.Close())
+		wg.Wait()
+	})
+}
+
+// 保存形式に無い形状
+type circle struct{}
+
+func (circle) Bounds() rtree.Rectangle                   { return rtree.NewBoundingBox(-1, -1, 1, 1) }
+func (circle) Intersects(rectangle rtree.Rectangle) bool { return true }
+func (circle) Covers(rectangle rtree.Rectangle) bool     { return false }
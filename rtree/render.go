@@ -0,0 +1,113 @@
+package rtree
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// 深さ毎の描画色
+//
+//nolint:gochecknoglobals
+var depthColors = []string{"#d62728", "#1f77b4", "#2ca02c", "#ff7f0e", "#9467bd", "#8c564b", "#e377c2", "#17becf"}
+
+// WriteDOT ノードの親子関係と短形をGraphvizのDOT形式で書き出す
+func (tree *RTree) WriteDOT(w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	names := make(map[*Node]string)
+
+	fmt.Fprintln(buf, "digraph rtree {")
+	fmt.Fprintln(buf, `  node [shape=box fontname="monospace"];`)
+
+	tree.Walk(func(node *Node, depth int) bool {
+		name := fmt.Sprintf("n%d", len(names))
+		names[node] = name
+
+		if node.DataID != nil {
+			fmt.Fprintf(buf, "  %s [shape=ellipse label=\"id %d\\n%s\"];\n", name, *node.DataID, node.Rectangle.label())
+		} else {
+			fmt.Fprintf(buf, "  %s [color=%q label=\"depth %d, %d children\\n%s\"];\n",
+				name, depthColors[depth%len(depthColors)], depth, len(node.Children), node.Rectangle.label())
+		}
+
+		if node.Parent != nil {
+			fmt.Fprintf(buf, "  %s -> %s;\n", names[node.Parent], name)
+		}
+
+		return true
+	})
+
+	fmt.Fprintln(buf, "}")
+
+	return buf.Flush()
+}
+
+// WriteSVG ノードの短形を幅widthのSVGで描画する. 0次元目(緯度)を縦、1次元目(経度)を横にとる
+// 中間ノードは深さ毎に色分けし、リーフエントリーは灰色で描く
+func (tree *RTree) WriteSVG(w io.Writer, width float64) error {
+	if tree.cnf.dimension() < defaultDimension {
+		return ErrInvalidRectangle
+	}
+
+	const padding = 10
+
+	buf := bufio.NewWriter(w)
+	bounds := tree.Root.Rectangle
+
+	// 空の木は最大空間のため描画しない
+	if len(tree.Root.Children) == 0 {
+		fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\"></svg>\n", width, float64(2*padding))
+		return buf.Flush()
+	}
+
+	latSpan := bounds[0].Second - bounds[0].First
+	lonSpan := bounds[1].Second - bounds[1].First
+
+	scale := (width - 2*padding) / math.Max(math.Max(lonSpan, latSpan), math.SmallestNonzeroFloat64)
+	height := latSpan*scale + 2*padding
+
+	// 北が上になるよう緯度を反転する
+	x := func(lon float64) float64 { return padding + (lon-bounds[1].First)*scale }
+	y := func(lat float64) float64 { return padding + (bounds[0].Second-lat)*scale }
+
+	fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%g\" height=\"%g\">\n", width, height)
+
+	tree.Walk(func(node *Node, depth int) bool {
+		r := node.Rectangle
+		var stroke, title string
+
+		if node.DataID != nil {
+			stroke, title = "#999999", fmt.Sprintf("id %d", *node.DataID)
+		} else {
+			stroke, title = depthColors[depth%len(depthColors)], fmt.Sprintf("depth %d", depth)
+		}
+
+		if node.DataID != nil && r[0].First == r[0].Second && r[1].First == r[1].Second {
+			fmt.Fprintf(buf, "  <circle cx=\"%g\" cy=\"%g\" r=\"2\" fill=%q><title>%s</title></circle>\n",
+				x(r[1].First), y(r[0].First), stroke, title)
+			return true
+		}
+
+		fmt.Fprintf(buf, "  <rect x=\"%g\" y=\"%g\" width=\"%g\" height=\"%g\" fill=\"none\" stroke=%q><title>%s</title></rect>\n",
+			x(r[1].First), y(r[0].Second), (r[1].Second-r[1].First)*scale, (r[0].Second-r[0].First)*scale, stroke, title)
+
+		return true
+	})
+
+	fmt.Fprintln(buf, "</svg>")
+
+	return buf.Flush()
+}
+
+// 短形の表示 [First, Second] x ...
+func (rectangle Rectangle) label() string {
+	parts := make([]string, len(rectangle))
+
+	for i, v := range rectangle {
+		parts[i] = fmt.Sprintf("[%g, %g]", v.First, v.Second)
+	}
+
+	return strings.Join(parts, " x ")
+}
@@ -0,0 +1,67 @@
+package rtree_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"rtree"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteDOT(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+	for i := 0; i < 3; i++ {
+		_ = tree.AddNode(tree.TakePlace(uint64(i), float64(i), float64(i)))
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.WriteDOT(&buf))
+
+	dot := buf.String()
+	assert.True(t, strings.HasPrefix(dot, "digraph rtree {"))
+	assert.True(t, strings.HasSuffix(dot, "}\n"))
+	assert.Contains(t, dot, `label="id 2\n[2, 2] x [2, 2]"`)
+	assert.Contains(t, dot, `label="depth 0, 2 children\n[0, 2] x [0, 2]"`)
+	// ルート以外のノードとエントリーに1本ずつ辺がある
+	assert.Equal(t, tree.Stats().Nodes-1+3, strings.Count(dot, "->"))
+}
+
+func TestWriteSVG(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
+
+	_ = tree.AddNode(tree.TakePlace(1, 0, 0))
+	_ = tree.AddNode(tree.TakePlace(2, 10, 20))
+	_ = tree.AddNode(tree.TakeRectangle(3, rect(5, 6, 5, 6)))
+
+	var buf bytes.Buffer
+	assert.NoError(t, tree.WriteSVG(&buf, 220))
+
+	type circle struct {
+		CX float64 `xml:"cx,attr"`
+		CY float64 `xml:"cy,attr"`
+	}
+
+	var svg struct {
+		Width   float64    `xml:"width,attr"`
+		Height  float64    `xml:"height,attr"`
+		Rects   []struct{} `xml:"rect"`
+		Circles []circle   `xml:"circle"`
+	}
+	assert.NoError(t, xml.Unmarshal(buf.Bytes(), &svg))
+
+	// 経度0..20 を幅200に描くため縮尺は10. 北が上
+	assert.EqualValues(t, 220, svg.Width)
+	assert.EqualValues(t, 120, svg.Height)
+	assert.Len(t, svg.Circles, 2)
+	assert.Contains(t, svg.Circles, circle{CX: 10, CY: 110})
+	assert.Equal(t, tree.Stats().Nodes+1, len(svg.Rects))
+
+	t.Run("empty", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, rtree.NewRTree(&rtree.Config{MaxEntrySize: 2}).WriteSVG(&buf, 100))
+		assert.Contains(t, buf.String(), "<svg")
+	})
+}
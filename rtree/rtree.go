@@ -1,23 +1,31 @@
 package rtree
 
 import (
+	"errors"
 	"fmt"
 	"math"
 )
 
 type (
 	RTree struct {
-		Root *Node
-		cnf  *Config
+		Root    *Node
+		cnf     *Config
+		entries map[uint64]*Node // DataIDからリーフエントリーへの索引
 	}
 	Config struct {
 		MaxEntrySize int
+		MinEntrySize int      // 0の場合はMaxEntrySizeの40%
+		Metric       Metric   // 近傍探索の距離. 既定はEuclidean
+		Packing      Packing  // BulkLoadの詰め込み方式. 既定はSTR
+		Strategy     Strategy // 挿入先の選択と分割の方式. 既定はLinear
+		Dimension    int      // 短形の次元数. 0の場合は2 (緯度, 経度)
 	}
 	Node struct {
 		Tree      *RTree
 		Parent    *Node
 		Rectangle Rectangle
-		DataID    *uint64 // リーフエントリーのみ存在
+		DataID    *uint64  // リーフエントリーのみ存在
+		Geometry  Geometry // リーフエントリーの厳密な形状. nilなら短形そのもの
 		Children  Nodes
 		// depth     uint8
 	}
@@ -32,26 +40,109 @@ type (
 )
 
 const (
-	dimCount = 2
+	defaultDimension = 2
+)
+
+var (
+	ErrNotFound         = errors.New("rtree: entry not found")
+	ErrDuplicateID      = errors.New("rtree: duplicate data id")
+	ErrInvalidRectangle = errors.New("rtree: invalid rectangle")
+	ErrInvalidConfig    = errors.New("rtree: invalid config")
 )
 
 func NewRTree(cnf *Config) (result *RTree) {
 	result = new(RTree)
 	result.cnf = cnf
+	result.entries = make(map[uint64]*Node)
 	result.Root = result.NewNode(nil)
-	result.Root.Rectangle = maxRectangle()
+	result.Root.Rectangle = maxRectangle(cnf.dimension())
+
+	return
+}
+
+// 短形の次元数
+func (cnf *Config) dimension() int {
+	if cnf.Dimension <= 0 {
+		return defaultDimension
+	}
+
+	return cnf.Dimension
+}
+
+// 短形の次元数が木の次元数と一致し、全ての区間が数値を持つか判定
+func (tree *RTree) validRectangle(rectangle Rectangle) bool {
+	return tree.cnf.validRectangle(rectangle)
+}
+
+// 挿入するエントリーの短形か判定. 区間は First <= Second に限る
+// First > Second の経度の区間は、探索短形でのみ経度180度を跨ぐ範囲として扱う
+func (tree *RTree) validEntry(rectangle Rectangle) bool {
+	if !tree.validRectangle(rectangle) {
+		return false
+	}
+
+	for _, interval := range rectangle {
+		if interval.Second < interval.First {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (cnf *Config) validRectangle(rectangle Rectangle) bool {
+	if len(rectangle) != cnf.dimension() {
+		return false
+	}
+
+	for _, interval := range rectangle {
+		if interval == nil || math.IsNaN(interval.First) || math.IsNaN(interval.Second) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ノードの最小エントリー数. 未指定ならMaxEntrySizeの40%とする
+func (cnf *Config) minEntrySize() (size int) {
+	size = cnf.MaxEntrySize * 2 / 5
+
+	if 0 < cnf.MinEntrySize {
+		// 分割後の両ノードが下限を満たせるよう半分までに制限する
+		size = cnf.MinEntrySize
+		if cnf.MaxEntrySize/2 < size {
+			size = cnf.MaxEntrySize / 2
+		}
+	}
+
+	if size < 1 {
+		size = 1
+	}
 
 	return
 }
 
 // ゼロ空間
-func zeroRectangle() Rectangle {
-	return Rectangle{&Inteval{First: 0.0, Second: 0.0}, &Inteval{First: 0.0, Second: 0.0}}
+func zeroRectangle(dim int) (rectangle Rectangle) {
+	rectangle = make(Rectangle, dim)
+
+	for i := range rectangle {
+		rectangle[i] = &Inteval{First: 0.0, Second: 0.0}
+	}
+
+	return
 }
 
 // 最大空間
-func maxRectangle() Rectangle {
-	return Rectangle{&Inteval{First: 0.0, Second: math.MaxFloat64}, &Inteval{First: 0.0, Second: math.MaxFloat64}}
+func maxRectangle(dim int) (rectangle Rectangle) {
+	rectangle = make(Rectangle, dim)
+
+	for i := range rectangle {
+		rectangle[i] = &Inteval{First: -math.MaxFloat64, Second: math.MaxFloat64}
+	}
+
+	return
 }
 
 func min(a, b float64) float64 {
@@ -75,12 +166,14 @@ func (tree *RTree) NewNode(parent *Node) (node *Node) {
 	node.Tree = tree
 	node.Parent = parent
 	node.Children = make([]*Node, 0, tree.cnf.MaxEntrySize)
-	node.Rectangle = make(Rectangle, dimCount)
-	node.Rectangle = zeroRectangle()
+	node.Rectangle = zeroRectangle(tree.cnf.dimension())
 
 	return
 }
 
+// Print ノードと子孫を標準出力へ書き出す
+//
+// Deprecated: 構造の確認は RTree.Walk, RTree.Stats, RTree.WriteDOT, RTree.WriteSVG を使う
 func (node *Node) Print(depth uint8) {
 	line := ""
 	loop := int(depth + 1)
@@ -96,7 +189,12 @@ func (node *Node) Print(depth uint8) {
 		fmt.Println("dataID", *node.DataID)
 	}
 
-	fmt.Println("rectangle ", node.Rectangle[0], node.Rectangle[1])
+	rectangle := []any{"rectangle "}
+	for _, v := range node.Rectangle {
+		rectangle = append(rectangle, v)
+	}
+
+	fmt.Println(rectangle...)
 	fmt.Println("depth", depth)
 	fmt.Println("children size", len(node.Children))
 
@@ -137,6 +235,11 @@ func (node *Node) isOverFlow() bool {
 	return node.Tree.cnf.MaxEntrySize < len(node.Children)
 }
 
+// エントリー数が下限を下回っているか判定する
+func (node *Node) isUnderFlow() bool {
+	return len(node.Children) < node.Tree.cnf.minEntrySize()
+}
+
 func (node *Node) isFullEntry() bool { //nolint
 	return node.Tree.cnf.MaxEntrySize == len(node.Children)
 }
@@ -186,15 +289,13 @@ func (rectangle *Rectangle) overlap(other Rectangle) bool {
 
 // 重なる面積
 func (rectangle Rectangle) overlapArea(other Rectangle) (area float64) {
+	area = 1
+
 	for i := range rectangle {
 		if !rectangle[i].overlap(*other[i]) {
 			return 0
 		}
 
-		if area == 0.0 {
-			area = 1
-		}
-
 		area *= rectangle[i].overlapArea(*other[i])
 	}
 
@@ -204,8 +305,9 @@ func (rectangle Rectangle) overlapArea(other Rectangle) (area float64) {
 // 短形間の中心同士の距離
 func (rectangle Rectangle) distance(other Rectangle) (distance float64) {
 	for i := range rectangle {
-		mid1 := (rectangle[i].First + rectangle[i].Second) / 2
-		mid2 := (other[i].First + other[i].Second) / 2
+		// 桁あふれしないよう半分にしてから足す
+		mid1 := rectangle[i].First/2 + rectangle[i].Second/2
+		mid2 := other[i].First/2 + other[i].Second/2
 
 		d := (mid1 - mid2)
 
@@ -217,7 +319,7 @@ func (rectangle Rectangle) distance(other Rectangle) (distance float64) {
 
 // 区間を完全に包含する
 func (internal Inteval) cover(other Inteval) bool {
-	return max(internal.First, other.First) <= min(other.Second, internal.Second)
+	return internal.First <= other.First && other.Second <= internal.Second
 }
 
 // 短形を包含するか判定
@@ -234,54 +336,86 @@ func (rectangle *Rectangle) cover(other Rectangle) bool {
 }
 
 // 探索短形が重なっている区間のIDを返却する
+//
+// Deprecated: Search を使用すること
 func (tree *RTree) FindAreas(root *Node, rectangle Rectangle) (results []*uint64, err error) {
-	if root != nil {
-		switch {
-		case root.isLeaf():
-			for i := range root.Children {
-				if root.Children[i].Rectangle.cover(rectangle) {
-					return []*uint64{root.Children[i].DataID}, nil
-				}
-			}
-		default:
-			for i := range root.Children {
-				if root.Children[i].Rectangle.overlap(rectangle) {
-					areas, err := tree.FindAreas(root.Children[i], rectangle)
-					if err != nil {
-						return nil, err
-					}
-
-					results = append(results, areas...)
-				}
-			}
-		}
+	if root == nil {
+		return nil, nil
+	}
+
+	if !tree.validRectangle(rectangle) {
+		return nil, ErrInvalidRectangle
 	}
 
+	root.search(rectangle, Intersects, func(entry *Node) bool {
+		results = append(results, entry.DataID)
+		return true
+	})
+
 	return
 }
 
 // ノードを挿入する
 func (tree *RTree) AddNode(src *Node) (err error) {
-	leaf, err := tree.findLeaf(tree.Root, src)
-	if err != nil {
-		return err
+	if !tree.validEntry(src.Rectangle) {
+		return ErrInvalidRectangle
 	}
 
-	leaf.AddEntry(src)
+	if src.DataID != nil {
+		if _, ok := tree.entries[*src.DataID]; ok {
+			return ErrDuplicateID
+		}
+	}
 
-	var newLeaf *Node
+	tree.insert(src)
 
-	if !leaf.isRoot() && leaf.isOverFlow() {
-		newLeaf = leaf.SplitNode()
+	if src.DataID != nil {
+		tree.entries[*src.DataID] = src
 	}
 
-	leaf.Adjust(newLeaf)
+	return
+}
+
+// リーフエントリーを木に挿入する
+func (tree *RTree) insert(src *Node) {
+	tree.insertAt(src, 0, make(map[int]bool))
+}
 
-	tree.Root.AdjustRoot()
+// 高さheightのノードへエントリーを挿入する. reinsertedはR*の強制再挿入を行った高さ
+func (tree *RTree) insertAt(src *Node, height int, reinserted map[int]bool) {
+	node := tree.chooseNode(src, height)
 
-	return
+	node.AddEntry(src)
+
+	// 挿入先から祖先へ向かって短形を調整し、オーバーフローしたノードを再挿入または分割する
+	for ; !node.isRoot(); height++ {
+		node.AdjustCoverRectangles()
+
+		// 分割でnodeが新ノード側へ移る場合があるため、元の親を保持しておく
+		parent := node.Parent
+
+		if node.isOverFlow() {
+			// R*: 高さ毎に一度だけ、分割の代わりに一部のエントリーを再挿入する
+			if tree.cnf.Strategy == RStar && !reinserted[height] {
+				reinserted[height] = true
+
+				for _, orphan := range node.pickReinsertEntries() {
+					tree.insertAt(orphan, height, reinserted)
+				}
+
+				return
+			}
+
+			node.SplitNode().AdjustCoverRectangles()
+		}
+
+		node = parent
+	}
+
+	tree.Root.AdjustRoot()
 }
 
+// ノードを分割する. 分割方法はConfig.Strategyに従う
 func (node *Node) SplitNode() (newNode *Node) {
 	// Linear-Cost Algorithm O(M)
 	// https://tanishiking24.hatenablog.com/entry/introduction_rtree_index
@@ -296,30 +430,61 @@ func (node *Node) SplitNode() (newNode *Node) {
 
 	node.deleteAllEntry()
 
-	baseDistances := node.listIntervalDistance()
+	one, another := existsChildren.split(node.Tree.cnf, node.listIntervalDistance())
+
+	for _, child := range one {
+		node.AddEntry(child)
+	}
+
+	for _, child := range another {
+		newNode.AddEntry(child)
+	}
+
+	return
+}
+
+// エントリーを2つに振り分ける. baseDistancesは線形分割で用いる次元毎の区間長
+func (nodes Nodes) split(cnf *Config, baseDistances []float64) (one, another Nodes) {
+	switch cnf.Strategy {
+	case Quadratic:
+		return nodes.quadraticSplit(cnf.minEntrySize())
+	case RStar:
+		return nodes.rstarSplit(cnf.minEntrySize())
+	default:
+		return nodes.linearSplit(baseDistances)
+	}
+}
 
-	for 0 < len(existsChildren) {
-		one, another := existsChildren.GetFarthestChildren(baseDistances)
-		node.AddEntry(one)
-		existsChildren.delete(one)
+// Linear-Cost Algorithm: 最も離れたエントリーの組を交互に振り分ける
+func (nodes Nodes) linearSplit(baseDistances []float64) (one, another Nodes) {
+	for 0 < len(nodes) {
+		first, second := nodes.GetFarthestChildren(baseDistances)
+		one = append(one, first)
+		nodes.delete(first)
 
-		if another != nil {
-			newNode.AddEntry(another)
-			existsChildren.delete(another)
+		// 同じエントリーが両端に選ばれた場合は片方にのみ追加する
+		if second != nil && second != first {
+			another = append(another, second)
+			nodes.delete(second)
 		}
 	}
 
+	// 常に同じエントリーが両端に選ばれると片方が空になるため、1つ移す
+	if len(another) == 0 && 1 < len(one) {
+		one, another = one[:len(one)-1], one[len(one)-1:]
+	}
+
 	return
 }
 
 // 全ての次元内で最も離れたエントリーを取得
 func (nodes *Nodes) GetFarthestChildren(distancesInDim []float64) (one *Node, another *Node) {
-	maxDistance := -1.0
+	maxDistance := math.Inf(-1)
 
 	for dim, baseDistance := range distancesInDim {
 		distance, tmpOne, tmpAnother := nodes.getFarthestChildrenInDim(dim, baseDistance)
 
-		if maxDistance < distance {
+		if one == nil || maxDistance < distance {
 			one, another = tmpOne, tmpAnother
 			maxDistance = distance
 		}
@@ -337,7 +502,7 @@ func (nodes *Nodes) getFarthestChildrenInDim(dim int, baseDistance float64) (dis
 	farthestPairs := make([]int, 2)
 
 	minSecond := math.MaxFloat64
-	maxFirst := -1.0
+	maxFirst := -math.MaxFloat64
 
 	for j := range *nodes {
 		if (*nodes)[j].Rectangle[dim].Second < minSecond {
@@ -351,33 +516,22 @@ func (nodes *Nodes) getFarthestChildrenInDim(dim int, baseDistance float64) (dis
 		}
 	}
 
-	distance = (maxFirst - minSecond) / baseDistance
+	distance = maxFirst - minSecond
+
+	// 区間長で正規化する. 区間長が0または桁あふれしている次元は正規化しない
+	if 0 < baseDistance && !math.IsInf(baseDistance, 0) {
+		distance /= baseDistance
+	}
+
+	if math.IsNaN(distance) {
+		distance = 0
+	}
 	one = (*nodes)[farthestPairs[0]]
 	another = (*nodes)[farthestPairs[1]]
 
 	return
 }
 
-func (node *Node) Adjust(newNode *Node) {
-	// ルートに到達したら終了
-	if !node.isRoot() {
-		node.AdjustCoverRectangles()
-
-		if newNode != nil {
-			newNode.AdjustCoverRectangles()
-		}
-
-		var newParentNode *Node
-
-		// 親がオーバーフローしたら分割する
-		if !node.Parent.isRoot() && node.Parent.isOverFlow() {
-			newParentNode = node.Parent.SplitNode()
-		}
-
-		node.Parent.Adjust(newParentNode)
-	}
-}
-
 // ルートの調整、ルートがオーバーフローしたら分割する
 func (node *Node) AdjustRoot() {
 	// ルートの調整
@@ -415,7 +569,7 @@ func (node *Node) AdjustRoot() {
 func (node *Node) AdjustCoverRectangles() {
 	for dim := range node.Rectangle {
 		newFirst := math.MaxFloat64
-		newSecond := -1.0
+		newSecond := -math.MaxFloat64
 
 		for i := range node.Children {
 			newFirst = min(newFirst, node.Children[i].Rectangle[dim].First)
@@ -427,47 +581,38 @@ func (node *Node) AdjustCoverRectangles() {
 	}
 }
 
-// ノードを挿入する葉ノードを探索する
-func (tree *RTree) findLeaf(root *Node, src *Node) (node *Node, err error) {
-	switch {
-	case root.isLeaf():
-		node = root // for lint
-	//	return root, nil
-	default:
-		var (
-			next        *Node
-			minDistance float64
-			nearestNode *Node
-			maxArea     float64
-		)
-
-		minDistance = math.MaxFloat64
-
-		for i := range root.Children {
-			tmpArea := root.Children[i].Rectangle.overlapArea(src.Rectangle)
-			// 重なりあり
-			if maxArea < tmpArea {
-				next = root.Children[i]
-				maxArea = tmpArea
-			} else {
-				// 重なりなし
-				distance := root.Children[i].Rectangle.distance(src.Rectangle)
-				if distance < minDistance {
-					minDistance = distance
-					nearestNode = root.Children[i]
-				}
-			}
-		}
+// 挿入先となる高さheightのノードを探索する. 葉ノードの高さを0とする
+func (tree *RTree) chooseNode(src *Node, height int) (node *Node) {
+	node = tree.Root
 
-		// 該当範囲がないなら最も近いノードを探索する
-		if next == nil {
-			next = nearestNode
-		}
+	for h := node.height(); height < h; h-- {
+		node = node.chooseSubtree(src)
+	}
+
+	return
+}
+
+// 葉ノードまでの高さ
+func (node *Node) height() (height int) {
+	for n := node; !n.isLeaf() && 0 < len(n.Children); n = n.Children[0] {
+		height++
+	}
 
-		node, err = tree.findLeaf(next, src)
+	return
+}
+
+// TakePoint 多次元の点のリーフエントリーを作成する
+func (tree *RTree) TakePoint(id uint64, point ...float64) (node *Node) {
+	node = tree.NewNode(nil)
+	node.Rectangle = make(Rectangle, len(point))
+
+	for i, p := range point {
+		node.Rectangle[i] = &Inteval{First: p, Second: p}
 	}
 
-	return //nolint
+	node.DataID = &id
+
+	return
 }
 
 func (tree *RTree) TakePlace(id uint64, lat, lon float64) (node *Node) {
@@ -1,7 +1,9 @@
 package rtree_test
 
 import (
+	"fmt"
 	"math"
+	"math/rand"
 	"rtree"
 	"testing"
 
@@ -35,7 +37,7 @@ func TestFindAreas(t *testing.T) {
 			_ = tree.AddNode(p)
 		}
 
-		tree.Root.Print(0)
+		assert.Equal(t, 2, tree.Stats().Height)
 
 		// root
 		// node node
@@ -67,7 +69,7 @@ func TestAddNode(t *testing.T) {
 			_ = tree.AddNode(p)
 		}
 
-		tree.Root.Print(0)
+		assert.Equal(t, 1, tree.Stats().Height)
 
 		// root
 		// 1 2 3
@@ -94,7 +96,7 @@ func TestAddNode(t *testing.T) {
 			_ = tree.AddNode(p)
 		}
 
-		tree.Root.Print(0)
+		assert.Equal(t, 3, tree.Stats().Height)
 
 		// root
 		// node      node
@@ -244,73 +246,166 @@ func _TestSplitNode(t *testing.T) {
 	})
 }
 
-func _TestAdjust(t *testing.T) {
-	t.Run("adjust node", func(t *testing.T) {
+func TestNegativeCoordinates(t *testing.T) {
+	t.Run("south west hemisphere", func(t *testing.T) {
 		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
 
-		nodes := []*rtree.Node{
-			tree.TakePlace(0, 100, 100),
-			tree.TakePlace(1, 200, 200),
-			tree.TakePlace(2, 10000, 20000),
+		// サンパウロ周辺
+		place := []*rtree.Node{
+			tree.TakePlace(1, -23.55, -46.63),
+			tree.TakePlace(2, -23.56, -46.65),
+			tree.TakePlace(3, -22.90, -43.17),
+			tree.TakePlace(4, -34.60, -58.38),
+			tree.TakePlace(5, -12.97, -38.50),
 		}
 
-		// 適当な中間ノード
-		node := tree.NewNode(tree.Root)
-
-		for i := range nodes {
-			node.AddEntry(nodes[i])
+		for _, p := range place {
+			_ = tree.AddNode(p)
 		}
 
-		// オーバーフローしているので分割
-		newNode := node.SplitNode()
+		assert.EqualValues(t, -34.60, tree.Root.Rectangle[0].First)
+		assert.EqualValues(t, -12.97, tree.Root.Rectangle[0].Second)
+		assert.EqualValues(t, -58.38, tree.Root.Rectangle[1].First)
+		assert.EqualValues(t, -38.50, tree.Root.Rectangle[1].Second)
+
+		ids, err := tree.Search(rtree.NewBoundingBox(-24, -47, -23, -46))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2}, ids)
 
-		node.Adjust(newNode)
+		ids, err = tree.Search(rtree.NewBoundingBox(-90, -180, 0, 0))
+		assert.NoError(t, err)
+		assert.Len(t, ids, 5)
+	})
+
+	t.Run("same coordinates", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
 
-		assert.EqualValues(t, 100, node.Rectangle[0].First)
-		assert.EqualValues(t, 200, node.Rectangle[0].Second)
-		assert.EqualValues(t, 100, node.Rectangle[1].First)
-		assert.EqualValues(t, 200, node.Rectangle[1].Second)
-		assert.EqualValues(t, 2, len(node.Children))
-		assert.Equal(t, tree.Root, node.Parent)
+		for i := uint64(0); i < 10; i++ {
+			assert.NoError(t, tree.AddNode(tree.TakePlace(i, -1.5, -1.5)))
+		}
 
-		assert.EqualValues(t, 10000, newNode.Rectangle[0].First)
-		assert.EqualValues(t, 10000, newNode.Rectangle[0].Second)
-		assert.EqualValues(t, 20000, newNode.Rectangle[1].First)
-		assert.EqualValues(t, 20000, newNode.Rectangle[1].Second)
-		assert.EqualValues(t, 1, len(newNode.Children))
-		assert.Equal(t, tree.Root, newNode.Parent)
+		ids, err := tree.Search(rtree.NewBoundingBox(-1.5, -1.5, -1.5, -1.5))
+		assert.NoError(t, err)
+		assert.Len(t, ids, 10)
 	})
 
-	t.Run("adjust parent(split root)", func(t *testing.T) {
+	t.Run("full float64 range", func(t *testing.T) {
 		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 2})
 
-		nodes := []*rtree.Node{
-			tree.TakePlace(0, 100, 100),
-			tree.TakePlace(1, 200, 200),
-			tree.TakePlace(2, 10000, 20000),
+		_ = tree.AddNode(tree.TakePlace(1, -math.MaxFloat64, math.MaxFloat64))
+		_ = tree.AddNode(tree.TakePlace(2, math.MaxFloat64, -math.MaxFloat64))
+		_ = tree.AddNode(tree.TakePlace(3, 0, 0))
+
+		ids, err := tree.Search(rtree.NewBoundingBox(-math.MaxFloat64, -math.MaxFloat64, math.MaxFloat64, math.MaxFloat64))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, ids)
+	})
+}
+
+func TestDimension(t *testing.T) {
+	r := rand.New(rand.NewSource(10))
+
+	for _, dimension := range []int{1, 3, 4} {
+		points := make([][]float64, 500)
+		entries := make([]rtree.Entry, len(points))
+
+		for i := range points {
+			points[i] = make([]float64, dimension)
+			for dim := range points[i] {
+				points[i][dim] = r.Float64() * 100
+			}
+		}
+
+		trees := map[string]*rtree.RTree{}
+
+		for _, strategy := range []rtree.Strategy{rtree.Linear, rtree.Quadratic, rtree.RStar} {
+			tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 5, Strategy: strategy, Dimension: dimension})
+
+			for i, p := range points {
+				assert.NoError(t, tree.AddNode(tree.TakePoint(uint64(i), p...)))
+			}
+
+			trees[fmt.Sprint("strategy ", strategy)] = tree
+		}
+
+		for _, packing := range []rtree.Packing{rtree.STR, rtree.Hilbert} {
+			tree := rtree.NewRTree(&rtree.Config{Dimension: dimension})
+
+			for i, p := range points {
+				entries[i] = rtree.Entry{ID: uint64(i), Rectangle: tree.TakePoint(0, p...).Rectangle}
+			}
+
+			tree, err := rtree.BulkLoad(&rtree.Config{MaxEntrySize: 5, Packing: packing, Dimension: dimension}, entries)
+			assert.NoError(t, err)
+
+			trees[fmt.Sprint("packing ", packing)] = tree
+		}
+
+		for name, tree := range trees {
+			t.Run(fmt.Sprintf("%dD %s", dimension, name), func(t *testing.T) {
+				query := make(rtree.Rectangle, dimension)
+				for dim := range query {
+					query[dim] = &rtree.Inteval{First: 20, Second: 70}
+				}
+
+				var want []uint64
+
+			next:
+				for i, p := range points {
+					for dim := range p {
+						if p[dim] < 20 || 70 < p[dim] {
+							continue next
+						}
+					}
+
+					want = append(want, uint64(i))
+				}
+
+				ids, err := tree.Search(query)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, want, ids)
+
+				results, err := tree.NearestPoint(points[7], 1)
+				assert.NoError(t, err)
+				assert.EqualValues(t, 7, results[0].ID)
+				assert.Zero(t, results[0].Distance)
+			})
 		}
+	}
+
+	t.Run("dimension mismatch", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 5, Dimension: 3})
+
+		assert.ErrorIs(t, tree.AddNode(tree.TakePlace(1, 1, 1)), rtree.ErrInvalidRectangle)
+		assert.NoError(t, tree.AddNode(tree.TakePoint(1, 1, 1, 1)))
+
+		_, err := tree.Search(rtree.NewBoundingBox(0, 0, 1, 1))
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+		_, err = tree.NearestPoint([]float64{1, 1}, 1)
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+	})
 
-		// 適当な中間ノード
-		oldRoot := tree.Root
+	t.Run("lat lon floor", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3, Dimension: 3, Metric: rtree.Haversine})
 
-		for i := range nodes {
-			oldRoot.AddEntry(nodes[i])
+		// 同じビルの各フロア
+		for floor := 1; floor <= 10; floor++ {
+			_ = tree.AddNode(tree.TakePoint(uint64(floor), 35.681236, 139.767125, float64(floor)))
 		}
 
-		// オーバーフローしているのでルートを分割
-		oldRoot.AdjustRoot()
-
-		// ルートが変わっているか確認
-		assert.NotEqual(t, tree.Root, oldRoot)
-		assert.Nil(t, tree.Root.Parent)
-		assert.Equal(t, tree.Root, tree.Root.Children[0].Parent)
-		assert.Equal(t, tree.Root, tree.Root.Children[1].Parent)
-		assert.EqualValues(t, 2, len(tree.Root.Children))
-
-		// 新ルートの確認
-		assert.EqualValues(t, 100, tree.Root.Rectangle[0].First)
-		assert.EqualValues(t, 10000, tree.Root.Rectangle[0].Second)
-		assert.EqualValues(t, 100, tree.Root.Rectangle[1].First)
-		assert.EqualValues(t, 20000, tree.Root.Rectangle[1].Second)
+		_ = tree.AddNode(tree.TakePoint(100, 35.690921, 139.700258, 1))
+
+		ids, err := tree.Search(rtree.Rectangle{
+			&rtree.Inteval{First: 35, Second: 36},
+			&rtree.Inteval{First: 139.7, Second: 139.8},
+			&rtree.Inteval{First: 3, Second: 5},
+		})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{3, 4, 5}, ids)
+
+		results, err := tree.WithinRadius(35.681236, 139.767125, 1000)
+		assert.NoError(t, err)
+		assert.Len(t, results, 10)
 	})
 }
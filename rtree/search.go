@@ -0,0 +1,197 @@
+package rtree
+
+import "iter"
+
+type (
+	// SearchMode 探索短形とエントリー短形の判定方法
+	SearchMode int
+
+	SearchOption func(*searchOption)
+
+	searchOption struct {
+		mode  SearchMode
+		limit int
+	}
+)
+
+const (
+	// Intersects 探索短形と重なるエントリー
+	Intersects SearchMode = iota
+	// Within 探索短形に包含されるエントリー
+	Within
+	// Contains 探索短形を包含するエントリー
+	Contains
+)
+
+// WithMode 判定方法を指定する. 既定は Intersects
+func WithMode(mode SearchMode) SearchOption {
+	return func(o *searchOption) {
+		o.mode = mode
+	}
+}
+
+// WithLimit 最初のn件で探索を打ち切る
+func WithLimit(n int) SearchOption {
+	return func(o *searchOption) {
+		o.limit = n
+	}
+}
+
+func newSearchOption(opts []SearchOption) (o *searchOption) {
+	o = new(searchOption)
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return
+}
+
+// Search 探索短形に該当する全てのリーフエントリーのIDを返却する
+// 経度の区間が First > Second の短形は経度180度を跨ぐ範囲として扱う (NewBoundingBox 参照)
+func (tree *RTree) Search(rectangle Rectangle, opts ...SearchOption) (results []uint64, err error) {
+	err = tree.SearchFunc(rectangle, func(id uint64) bool {
+		results = append(results, id)
+		return true
+	}, opts...)
+
+	return
+}
+
+// SearchFunc 探索短形に該当するリーフエントリー毎にfnを呼び出す. fnがfalseを返したら打ち切る
+func (tree *RTree) SearchFunc(rectangle Rectangle, fn func(id uint64) bool, opts ...SearchOption) (err error) {
+	return tree.searchEntries(rectangle, opts, func(entry *Node) bool {
+		return fn(*entry.DataID)
+	})
+}
+
+// 探索短形に該当するリーフエントリー毎にfnを呼び出す
+func (tree *RTree) searchEntries(rectangle Rectangle, opts []SearchOption, fn func(entry *Node) bool) (err error) {
+	if !tree.validRectangle(rectangle) {
+		return ErrInvalidRectangle
+	}
+
+	o := newSearchOption(opts)
+	count := 0
+
+	tree.searchParts(tree.cnf.Metric.splitAntimeridian(rectangle), o.mode, func(entry *Node) bool {
+		count++
+
+		if !fn(entry) {
+			return false
+		}
+
+		return o.limit <= 0 || count < o.limit
+	})
+
+	return
+}
+
+// All 探索短形に該当するリーフエントリーのIDを列挙する. 短形が不正な場合は何も返さない
+func (tree *RTree) All(rectangle Rectangle, opts ...SearchOption) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		_ = tree.SearchFunc(rectangle, yield, opts...)
+	}
+}
+
+// 経度180度で分割した短形を探索する. 同じエントリーは一度だけfnに渡す
+func (tree *RTree) searchParts(parts []Rectangle, mode SearchMode, fn func(entry *Node) bool) {
+	if len(parts) == 1 {
+		tree.Root.search(parts[0], mode, fn)
+		return
+	}
+
+	found := make(map[*Node]bool)
+
+	for _, part := range parts {
+		next := tree.Root.search(part, mode, func(entry *Node) bool {
+			if found[entry] {
+				return true
+			}
+
+			// 包含判定は分割した全ての短形を包含する必要がある
+			if mode == Contains {
+				for _, other := range parts {
+					if !mode.matchEntry(entry, other) {
+						return true
+					}
+				}
+			}
+
+			found[entry] = true
+
+			return fn(entry)
+		})
+
+		if !next {
+			return
+		}
+	}
+}
+
+// 該当するリーフエントリー毎にfnを呼び出す. 打ち切られたらfalseを返す
+func (node *Node) search(rectangle Rectangle, mode SearchMode, fn func(entry *Node) bool) bool {
+	for _, child := range node.Children {
+		if child.DataID != nil {
+			if mode.matchEntry(child, rectangle) && !fn(child) {
+				return false
+			}
+
+			continue
+		}
+
+		if mode.prune(child.Rectangle, rectangle) {
+			continue
+		}
+
+		if !child.search(rectangle, mode, fn) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// エントリー短形が条件を満たすか判定
+func (mode SearchMode) match(entry, rectangle Rectangle) bool {
+	switch mode {
+	case Within:
+		return rectangle.cover(entry)
+	case Contains:
+		return entry.cover(rectangle)
+	default:
+		return entry.overlap(rectangle)
+	}
+}
+
+// リーフエントリーが条件を満たすか判定. 形状を持つエントリーは形状で判定する
+func (mode SearchMode) matchEntry(entry *Node, rectangle Rectangle) bool {
+	return mode.matchGeometry(entry.Rectangle, entry.Geometry, rectangle)
+}
+
+// 外接短形がentryで形状がgeometryのリーフエントリーが条件を満たすか判定
+func (mode SearchMode) matchGeometry(entry Rectangle, geometry Geometry, rectangle Rectangle) bool {
+	if !mode.match(entry, rectangle) {
+		return false
+	}
+
+	// 外接短形が包含されれば形状も包含される
+	if geometry == nil || mode == Within {
+		return true
+	}
+
+	if mode == Contains {
+		return geometry.Covers(rectangle)
+	}
+
+	return geometry.Intersects(rectangle)
+}
+
+// 中間ノード配下に該当エントリーが存在し得ないか判定
+func (mode SearchMode) prune(node, rectangle Rectangle) bool {
+	if mode == Contains {
+		return !node.cover(rectangle)
+	}
+
+	return !node.overlap(rectangle)
+}
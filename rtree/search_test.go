@@ -0,0 +1,92 @@
+package rtree_test
+
+import (
+	"rtree"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func rect(first0, second0, first1, second1 float64) rtree.Rectangle {
+	return rtree.Rectangle{
+		&rtree.Inteval{First: first0, Second: second0},
+		&rtree.Inteval{First: first1, Second: second1},
+	}
+}
+
+func TestSearch(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+	// 5x5の格子点 id = x*10+y
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 5; y++ {
+			_ = tree.AddNode(tree.TakePlace(uint64(x*10+y), float64(x), float64(y)))
+		}
+	}
+
+	t.Run("intersects", func(t *testing.T) {
+		ids, err := tree.Search(rect(1, 2, 2, 4))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{12, 13, 14, 22, 23, 24}, ids)
+	})
+
+	t.Run("no hit", func(t *testing.T) {
+		ids, err := tree.Search(rect(10, 20, 10, 20))
+		assert.NoError(t, err)
+		assert.Empty(t, ids)
+	})
+
+	t.Run("within", func(t *testing.T) {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 3})
+
+		areas := []rtree.Rectangle{
+			rect(0, 1, 0, 1),
+			rect(0, 5, 0, 5),
+			rect(2, 3, 2, 3),
+		}
+
+		for i, a := range areas {
+			n := tree.TakePlace(uint64(i), 0, 0)
+			n.Rectangle = a
+			_ = tree.AddNode(n)
+		}
+
+		ids, err := tree.Search(rect(0, 3, 0, 3), rtree.WithMode(rtree.Within))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{0, 2}, ids)
+
+		ids, err = tree.Search(rect(2.5, 2.5, 2.5, 2.5), rtree.WithMode(rtree.Contains))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2}, ids)
+
+		ids, err = tree.Search(rect(0.5, 2.5, 0.5, 2.5))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{0, 1, 2}, ids)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		ids, err := tree.Search(rect(0, 4, 0, 4), rtree.WithLimit(4))
+		assert.NoError(t, err)
+		assert.Len(t, ids, 4)
+	})
+
+	t.Run("func and iterator", func(t *testing.T) {
+		var ids []uint64
+
+		err := tree.SearchFunc(rect(0, 0, 0, 4), func(id uint64) bool {
+			ids = append(ids, id)
+			return len(ids) < 2
+		})
+		assert.NoError(t, err)
+		assert.Len(t, ids, 2)
+
+		ids = slices.Collect(tree.All(rect(0, 0, 0, 4)))
+		assert.ElementsMatch(t, []uint64{0, 1, 2, 3, 4}, ids)
+	})
+
+	t.Run("invalid rectangle", func(t *testing.T) {
+		_, err := tree.Search(rtree.Rectangle{&rtree.Inteval{}})
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+	})
+}
@@ -0,0 +1,450 @@
+package rtree
+
+import (
+	"errors"
+	"iter"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+type (
+	// Snapshot 不変のRTree. AddNode/Delete は新しい版を返し、元の版は変更されない
+	// 変更の無い部分木は元の版と共有する (経路複製) ため、版を保持するコストは小さい
+	// 版は変更されないため、複数のゴルーチンから同時に探索できる
+	Snapshot struct {
+		cnf   *Config
+		root  *Node
+		index *idIndex
+		size  int
+	}
+
+	// idIndex DataIDからリーフエントリーへの永続的な索引
+	// IDの4ビット毎に分岐するトライで、要素が1つの部分木は葉にまとめる. 更新は経路を複製する
+	idIndex struct {
+		entry    *Node
+		children *[16]*idIndex
+	}
+
+	// VersionedRTree 最新の版を保持し、更新の度に新しい版へ置き換える
+	// 探索中の版は更新の影響を受けず、Restore で以前の版へ戻すことができる
+	VersionedRTree struct {
+		mu      sync.Mutex
+		current atomic.Pointer[Snapshot]
+	}
+)
+
+var ErrNoDataID = errors.New("rtree: entry has no data id")
+
+// NewSnapshot 空の版を作成する
+func NewSnapshot(cnf *Config) *Snapshot {
+	c := *cnf
+
+	return &Snapshot{cnf: &c, root: &Node{Rectangle: maxRectangle(c.dimension())}}
+}
+
+// Len エントリーの数
+func (s *Snapshot) Len() int {
+	return s.size
+}
+
+// Has DataIDのエントリーが存在するか判定
+func (s *Snapshot) Has(id uint64) bool {
+	return s.index.get(id) != nil
+}
+
+// AddNode リーフエントリーを加えた版を返す. srcは TakePlace 等で作成したもので、複製して格納する
+func (s *Snapshot) AddNode(src *Node) (*Snapshot, error) {
+	if src.DataID == nil {
+		return nil, ErrNoDataID
+	}
+
+	if !s.view().validEntry(src.Rectangle) {
+		return nil, ErrInvalidRectangle
+	}
+
+	if s.Has(*src.DataID) {
+		return nil, ErrDuplicateID
+	}
+
+	id := *src.DataID
+	entry := &Node{Rectangle: src.Rectangle.clone(), DataID: &id, Geometry: src.Geometry}
+
+	return &Snapshot{
+		cnf:   s.cnf,
+		root:  s.insertRoot(s.root, entry),
+		index: s.index.set(id, entry, 0),
+		size:  s.size + 1,
+	}, nil
+}
+
+// Delete DataIDのエントリーを除いた版を返す
+func (s *Snapshot) Delete(id uint64) (*Snapshot, error) {
+	entry := s.index.get(id)
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+
+	root, orphans, _ := s.remove(s.root, entry)
+
+	// 子が1つだけのルートを畳み込む
+	for len(root.Children) == 1 && root.Children[0].DataID == nil {
+		root = root.Children[0]
+	}
+
+	if len(root.Children) == 0 {
+		root = &Node{Rectangle: maxRectangle(s.cnf.dimension())}
+	}
+
+	// 下限を下回って外れたノード配下のエントリーを再挿入する
+	for _, orphan := range orphans {
+		root = s.insertRoot(root, orphan)
+	}
+
+	return &Snapshot{
+		cnf:   s.cnf,
+		root:  root,
+		index: s.index.delete(id, 0),
+		size:  s.size - 1,
+	}, nil
+}
+
+// Update DataIDのエントリーを新しい短形へ移動した版を返す. 形状は外れる (RTree.Update 参照)
+func (s *Snapshot) Update(id uint64, rectangle Rectangle) (*Snapshot, error) {
+	if !s.view().validEntry(rectangle) {
+		return nil, ErrInvalidRectangle
+	}
+
+	entry := s.index.get(id)
+	if entry == nil {
+		return nil, ErrNotFound
+	}
+
+	deleted, err := s.Delete(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted.AddNode(&Node{Rectangle: rectangle, DataID: &id})
+}
+
+// Search RTree.Search と同じ
+func (s *Snapshot) Search(rectangle Rectangle, opts ...SearchOption) ([]uint64, error) {
+	return s.view().Search(rectangle, opts...)
+}
+
+// SearchFunc RTree.SearchFunc と同じ
+func (s *Snapshot) SearchFunc(rectangle Rectangle, fn func(id uint64) bool, opts ...SearchOption) error {
+	return s.view().SearchFunc(rectangle, fn, opts...)
+}
+
+// All RTree.All と同じ
+func (s *Snapshot) All(rectangle Rectangle, opts ...SearchOption) iter.Seq[uint64] {
+	return s.view().All(rectangle, opts...)
+}
+
+// Nearest RTree.Nearest と同じ
+func (s *Snapshot) Nearest(lat, lon float64, k int, opts ...NearestOption) ([]Neighbor, error) {
+	return s.view().Nearest(lat, lon, k, opts...)
+}
+
+// WithinRadius RTree.WithinRadius と同じ
+func (s *Snapshot) WithinRadius(lat, lon, meters float64) ([]Neighbor, error) {
+	return s.view().WithinRadius(lat, lon, meters)
+}
+
+// Stats RTree.Stats と同じ
+func (s *Snapshot) Stats() Stats {
+	return s.view().Stats()
+}
+
+// 探索用の木. ノードの親と所属する木は持たないため、変更する操作には使えない
+func (s *Snapshot) view() *RTree {
+	return &RTree{Root: s.root, cnf: s.cnf}
+}
+
+// Diff fromからtoへの差分. 追加されたIDと削除されたIDを昇順に返す
+// 同じIDで別のエントリーに置き換えられたもの(Update 等)は両方に含まれる
+// 索引の共有されている部分木は比較しないため、差分の大きさに比例した時間で求まる
+func Diff(from, to *Snapshot) (added, removed []uint64) {
+	diffIndex(from.index, to.index, 0, &added, &removed)
+
+	slices.Sort(added)
+	slices.Sort(removed)
+
+	return
+}
+
+// nodeの複製へエントリーを挿入する. 上限を超えたら分割した2つ目のノードも返す
+func (s *Snapshot) insert(node, entry *Node) (one, another *Node) {
+	children := make(Nodes, len(node.Children), len(node.Children)+1)
+	copy(children, node.Children)
+
+	if len(children) == 0 || children[0].DataID != nil {
+		children = append(children, entry)
+	} else {
+		i := slices.Index(children, children.chooseSubtree(s.cnf, entry.Rectangle))
+
+		child, split := s.insert(children[i], entry)
+		children[i] = child
+
+		if split != nil {
+			children = append(children, split)
+		}
+	}
+
+	if len(children) <= s.cnf.MaxEntrySize {
+		return s.newNode(children), nil
+	}
+
+	base := make([]float64, len(node.Rectangle))
+	for dim, v := range node.Rectangle {
+		base[dim] = v.Second - v.First
+	}
+
+	first, second := children.split(s.cnf, base)
+
+	return s.newNode(first), s.newNode(second)
+}
+
+// 挿入でルートが分割されたら新しいルートを作る
+func (s *Snapshot) insertRoot(root, entry *Node) *Node {
+	one, another := s.insert(root, entry)
+	if another == nil {
+		return one
+	}
+
+	return s.newNode(Nodes{one, another})
+}
+
+// エントリーを除いたnodeの複製. 下限を下回ったノードはnilとし、配下のエントリーをorphansに返す
+func (s *Snapshot) remove(node, entry *Node) (result *Node, orphans Nodes, found bool) {
+	for i, child := range node.Children {
+		var replaced *Node
+
+		switch {
+		case child == entry:
+			found = true
+		case child.DataID == nil && child.Rectangle.cover(entry.Rectangle):
+			replaced, orphans, found = s.remove(child, entry)
+		}
+
+		if !found {
+			continue
+		}
+
+		children := slices.Clone(node.Children)
+		if replaced != nil {
+			children[i] = replaced
+		} else {
+			children = slices.Delete(children, i, i+1)
+		}
+
+		if node != s.root && len(children) < s.cnf.minEntrySize() {
+			for _, c := range children {
+				orphans = append(orphans, c.listDataNodes()...)
+			}
+
+			return nil, orphans, true
+		}
+
+		return s.newNode(children), orphans, true
+	}
+
+	return node, nil, false
+}
+
+// 子を包むノード. 作成後は変更しない
+func (s *Snapshot) newNode(children Nodes) (node *Node) {
+	node = &Node{Rectangle: zeroRectangle(s.cnf.dimension()), Children: children}
+	node.AdjustCoverRectangles()
+
+	return
+}
+
+func (index *idIndex) get(id uint64) *Node {
+	for shift := 0; index != nil; shift += 4 {
+		if index.children == nil {
+			if *index.entry.DataID == id {
+				return index.entry
+			}
+
+			return nil
+		}
+
+		index = index.children[id>>shift&15]
+	}
+
+	return nil
+}
+
+// idのエントリーを設定した索引を返す
+func (index *idIndex) set(id uint64, entry *Node, shift int) *idIndex {
+	switch {
+	case index == nil:
+		return &idIndex{entry: entry}
+	case index.children == nil:
+		if *index.entry.DataID == id {
+			return &idIndex{entry: entry}
+		}
+
+		// 葉を分岐に置き換える
+		branch := &idIndex{children: new([16]*idIndex)}
+		branch.children[*index.entry.DataID>>shift&15] = index
+
+		return branch.set(id, entry, shift)
+	default:
+		children := *index.children
+		children[id>>shift&15] = children[id>>shift&15].set(id, entry, shift+4)
+
+		return &idIndex{children: &children}
+	}
+}
+
+// idのエントリーを除いた索引を返す
+func (index *idIndex) delete(id uint64, shift int) *idIndex {
+	switch {
+	case index == nil:
+		return nil
+	case index.children == nil:
+		if *index.entry.DataID == id {
+			return nil
+		}
+
+		return index
+	}
+
+	children := *index.children
+	children[id>>shift&15] = children[id>>shift&15].delete(id, shift+4)
+
+	// 残りが葉1つなら分岐をまとめる
+	var rest *idIndex
+
+	count := 0
+
+	for _, child := range children {
+		if child != nil {
+			rest = child
+			count++
+		}
+	}
+
+	switch {
+	case count == 0:
+		return nil
+	case count == 1 && rest.children == nil:
+		return rest
+	}
+
+	return &idIndex{children: &children}
+}
+
+// 分岐毎の部分木. 葉はIDの該当する位置のみに置く
+func (index *idIndex) branches(shift int) (children [16]*idIndex) {
+	switch {
+	case index == nil:
+	case index.children == nil:
+		children[*index.entry.DataID>>shift&15] = index
+	default:
+		children = *index.children
+	}
+
+	return
+}
+
+func diffIndex(from, to *idIndex, shift int, added, removed *[]uint64) {
+	if from == to {
+		return
+	}
+
+	if from != nil && to != nil && from.children == nil && to.children == nil {
+		if from.entry != to.entry {
+			*removed = append(*removed, *from.entry.DataID)
+			*added = append(*added, *to.entry.DataID)
+		}
+
+		return
+	}
+
+	if to == nil {
+		from.each(func(id uint64) { *removed = append(*removed, id) })
+		return
+	}
+
+	if from == nil {
+		to.each(func(id uint64) { *added = append(*added, id) })
+		return
+	}
+
+	fromChildren, toChildren := from.branches(shift), to.branches(shift)
+
+	for i := range fromChildren {
+		diffIndex(fromChildren[i], toChildren[i], shift+4, added, removed)
+	}
+}
+
+func (index *idIndex) each(fn func(id uint64)) {
+	switch {
+	case index == nil:
+	case index.children == nil:
+		fn(*index.entry.DataID)
+	default:
+		for _, child := range index.children {
+			child.each(fn)
+		}
+	}
+}
+
+func NewVersionedRTree(cnf *Config) *VersionedRTree {
+	v := new(VersionedRTree)
+	v.current.Store(NewSnapshot(cnf))
+
+	return v
+}
+
+// Snapshot 現在の版. 以降の更新の影響を受けない
+func (v *VersionedRTree) Snapshot() *Snapshot {
+	return v.current.Load()
+}
+
+// Restore 版を置き換える. 取り込みに失敗した場合に以前の版へ戻す
+func (v *VersionedRTree) Restore(s *Snapshot) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.current.Store(s)
+}
+
+func (v *VersionedRTree) AddNode(src *Node) error {
+	return v.Apply(func(s *Snapshot) (*Snapshot, error) {
+		return s.AddNode(src)
+	})
+}
+
+func (v *VersionedRTree) Delete(id uint64) error {
+	return v.Apply(func(s *Snapshot) (*Snapshot, error) {
+		return s.Delete(id)
+	})
+}
+
+func (v *VersionedRTree) Update(id uint64, rectangle Rectangle) error {
+	return v.Apply(func(s *Snapshot) (*Snapshot, error) {
+		return s.Update(id, rectangle)
+	})
+}
+
+// Apply 現在の版にfnを適用した版へ置き換える. fnがエラーを返したら版は変わらない
+// 複数の更新をまとめて適用する場合に使う. 適用中の更新は直列化される
+func (v *VersionedRTree) Apply(fn func(s *Snapshot) (*Snapshot, error)) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	next, err := fn(v.current.Load())
+	if err != nil {
+		return err
+	}
+
+	v.current.Store(next)
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package rtree_test
+
+import (
+	"maps"
+	"math/rand"
+	"rtree"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	everything := rect(-1000, 1000, -1000, 1000)
+
+	t.Run("versions", func(t *testing.T) {
+		for _, strategy := range []rtree.Strategy{rtree.Linear, rtree.Quadratic, rtree.RStar} {
+			r := rand.New(rand.NewSource(71 + int64(strategy)))
+			scratch := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+
+			snapshot := rtree.NewSnapshot(&rtree.Config{MaxEntrySize: 4, Strategy: strategy})
+			oracle := make(map[uint64]rtree.Rectangle)
+
+			var (
+				versions []*rtree.Snapshot
+				states   []map[uint64]rtree.Rectangle
+			)
+
+			for i := 0; i < 600; i++ {
+				id := uint64(r.Intn(200))
+				_, exists := oracle[id]
+
+				var err error
+
+				switch {
+				case exists && r.Intn(2) == 0:
+					snapshot, err = snapshot.Delete(id)
+					delete(oracle, id)
+				case exists:
+					x, y := r.Float64()*100, r.Float64()*100
+					snapshot, err = snapshot.Update(id, rect(x, x, y, y))
+					oracle[id] = rect(x, x, y, y)
+				default:
+					x, y := r.Float64()*100, r.Float64()*100
+					snapshot, err = snapshot.AddNode(scratch.TakePlace(id, x, y))
+					oracle[id] = rect(x, x, y, y)
+				}
+
+				assert.NoError(t, err)
+
+				versions = append(versions, snapshot)
+				states = append(states, maps.Clone(oracle))
+			}
+
+			// 以前の版は後の更新の影響を受けない
+			for i, version := range versions {
+				state := states[i]
+
+				assert.Equal(t, len(state), version.Len())
+
+				ids, err := version.Search(everything)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, slices.Collect(maps.Keys(state)), ids)
+
+				stats := version.Stats()
+				assert.Equal(t, len(state), stats.Entries)
+				assert.Equal(t, len(state), stats.Levels[stats.Height-1].Entries, "葉の深さが揃っている")
+
+				if i%50 != 0 {
+					continue
+				}
+
+				query := rect(20, 60, 30, 70)
+
+				var expected []uint64
+
+				for id, r := range state {
+					if overlaps(r, query) {
+						expected = append(expected, id)
+					}
+				}
+
+				ids, _ = version.Search(query)
+				assert.ElementsMatch(t, expected, ids)
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		scratch := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		s, _ := rtree.NewSnapshot(&rtree.Config{MaxEntrySize: 4}).AddNode(scratch.TakePlace(1, 0, 0))
+
+		_, err := s.AddNode(scratch.TakePlace(1, 1, 1))
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+
+		_, err = s.AddNode(scratch.TakePoint(2, 1, 2, 3))
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+
+		_, err = s.AddNode(scratch.NewNode(nil))
+		assert.ErrorIs(t, err, rtree.ErrNoDataID)
+
+		_, err = s.Delete(2)
+		assert.ErrorIs(t, err, rtree.ErrNotFound)
+
+		_, err = s.Update(2, rect(0, 0, 0, 0))
+		assert.ErrorIs(t, err, rtree.ErrNotFound)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		scratch := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+		s := rtree.NewSnapshot(&rtree.Config{MaxEntrySize: 4, Metric: rtree.Haversine})
+
+		for id, p := range [][2]float64{{35.681236, 139.767125}, {35.690921, 139.700258}, {34.733165, 135.500214}} {
+			s, _ = s.AddNode(scratch.TakePlace(uint64(id), p[0], p[1]))
+		}
+
+		neighbors, err := s.Nearest(35.68, 139.76, 2)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 0, neighbors[0].ID)
+		assert.EqualValues(t, 1, neighbors[1].ID)
+
+		neighbors, _ = s.WithinRadius(35.68, 139.76, 10000)
+		assert.Len(t, neighbors, 2)
+
+		assert.True(t, s.Has(2))
+		assert.Equal(t, []uint64{2}, slices.Collect(s.All(rtree.NewBoundingBox(34, 135, 35, 136))))
+	})
+}
+
+func TestDiff(t *testing.T) {
+	scratch := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+	base := rtree.NewSnapshot(&rtree.Config{MaxEntrySize: 4})
+
+	for i := 0; i < 1000; i++ {
+		base, _ = base.AddNode(scratch.TakePlace(uint64(i*7919), float64(i%37), float64(i%41)))
+	}
+
+	next := base
+	next, _ = next.Delete(7919 * 3)
+	next, _ = next.Delete(7919 * 500)
+	next, _ = next.AddNode(scratch.TakePlace(1, 1, 1))
+	next, _ = next.AddNode(scratch.TakePlace(7919*3, 5, 5))
+	next, _ = next.Update(7919*10, rect(2, 2, 2, 2))
+	next, _ = next.AddNode(scratch.TakePlace(2, 2, 2))
+	next, _ = next.Delete(2)
+
+	added, removed := rtree.Diff(base, next)
+	assert.Equal(t, []uint64{1, 7919 * 3, 7919 * 10}, added)
+	assert.Equal(t, []uint64{7919 * 3, 7919 * 10, 7919 * 500}, removed)
+
+	added, removed = rtree.Diff(next, base)
+	assert.Equal(t, []uint64{7919 * 3, 7919 * 10, 7919 * 500}, added)
+	assert.Equal(t, []uint64{1, 7919 * 3, 7919 * 10}, removed)
+
+	added, removed = rtree.Diff(base, base)
+	assert.Empty(t, added)
+	assert.Empty(t, removed)
+
+	added, removed = rtree.Diff(rtree.NewSnapshot(&rtree.Config{MaxEntrySize: 4}), base)
+	assert.Len(t, added, 1000)
+	assert.Empty(t, removed)
+}
+
+func TestVersionedRTree(t *testing.T) {
+	scratch := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+	tree := rtree.NewVersionedRTree(&rtree.Config{MaxEntrySize: 4})
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, tree.AddNode(scratch.TakePlace(uint64(i), float64(i), float64(i))))
+	}
+
+	t.Run("rollback", func(t *testing.T) {
+		before := tree.Snapshot()
+
+		// 途中で失敗した一括取り込みは反映されない
+		err := tree.Apply(func(s *rtree.Snapshot) (*rtree.Snapshot, error) {
+			for i := 100; i < 110; i++ {
+				var err error
+				if s, err = s.AddNode(scratch.TakePlace(uint64(i%105), 0, 0)); err != nil {
+					return nil, err
+				}
+			}
+
+			return s, nil
+		})
+		assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+		assert.Same(t, before, tree.Snapshot())
+
+		assert.NoError(t, tree.Delete(0))
+		assert.NoError(t, tree.Update(1, rect(50, 50, 50, 50)))
+		assert.Equal(t, 99, tree.Snapshot().Len())
+
+		tree.Restore(before)
+		assert.Equal(t, 100, tree.Snapshot().Len())
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		var wg sync.WaitGroup
+
+		for g := 0; g < 4; g++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+
+				for i := 0; i < 200; i++ {
+					s := tree.Snapshot()
+					ids, err := s.Search(rect(-1, 1000, -1, 1000))
+					assert.NoError(t, err)
+					assert.Len(t, ids, s.Len())
+				}
+			}()
+		}
+
+		for i := 100; i < 300; i++ {
+			assert.NoError(t, tree.AddNode(scratch.TakePlace(uint64(i), float64(i), 0)))
+		}
+
+		wg.Wait()
+	})
+}
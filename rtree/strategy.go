@@ -0,0 +1,312 @@
+package rtree
+
+import (
+	"cmp"
+	"math"
+	"slices"
+)
+
+// Strategy 挿入先の選択とノード分割の方式
+type Strategy int
+
+const (
+	// Linear Guttmanの面積増加最小の挿入先選択と線形分割 O(M)
+	Linear Strategy = iota
+	// Quadratic Guttmanの面積増加最小の挿入先選択と二次分割 O(M^2)
+	Quadratic
+	// RStar R*-tree. 重なり増加最小の挿入先選択、周長による分割軸の選択と強制再挿入
+	RStar
+)
+
+// R*で強制再挿入するエントリーの割合
+const reinsertRatio = 0.3
+
+// 挿入先の子ノードを選択する
+func (node *Node) chooseSubtree(src *Node) *Node {
+	return node.Children.chooseSubtree(node.Tree.cnf, src.Rectangle)
+}
+
+func (nodes Nodes) chooseSubtree(cnf *Config, rectangle Rectangle) *Node {
+	// R*: 子が葉ノードなら重なりの増加が最小のものを選ぶ
+	if cnf.Strategy == RStar && nodes[0].isLeaf() {
+		return nodes.leastOverlapEnlargement(rectangle)
+	}
+
+	return nodes.leastEnlargement(rectangle)
+}
+
+// 面積の増加が最小のノード. 同じなら面積が小さい、さらに同じなら中心が近いノード
+func (nodes Nodes) leastEnlargement(rectangle Rectangle) (next *Node) {
+	var best []float64
+
+	for _, child := range nodes {
+		area := child.Rectangle.area()
+		score := []float64{
+			child.Rectangle.union(rectangle).area() - area,
+			area,
+			child.Rectangle.distance(rectangle),
+		}
+
+		if next == nil || slices.Compare(score, best) < 0 {
+			next, best = child, score
+		}
+	}
+
+	return
+}
+
+// 兄弟ノードとの重なりの増加が最小のノード. 同じなら面積の増加が最小のノード
+func (nodes Nodes) leastOverlapEnlargement(rectangle Rectangle) (next *Node) {
+	var best []float64
+
+	for _, child := range nodes {
+		enlarged := child.Rectangle.union(rectangle)
+
+		var overlap float64
+
+		for _, sibling := range nodes {
+			if sibling != child {
+				overlap += enlarged.overlapArea(sibling.Rectangle) - child.Rectangle.overlapArea(sibling.Rectangle)
+			}
+		}
+
+		area := child.Rectangle.area()
+		score := []float64{
+			overlap,
+			enlarged.area() - area,
+			area,
+			child.Rectangle.distance(rectangle),
+		}
+
+		if next == nil || slices.Compare(score, best) < 0 {
+			next, best = child, score
+		}
+	}
+
+	return
+}
+
+// Quadratic-Cost Algorithm: 無駄な面積が最大の組を種に、振り分けの差が大きいエントリーから順に割り当てる
+func (nodes Nodes) quadraticSplit(minSize int) (one, another Nodes) {
+	// PickSeeds
+	seed1, seed2 := 0, 1
+
+	var worst []float64
+
+	for i := range nodes {
+		for j := i + 1; j < len(nodes); j++ {
+			union := nodes[i].Rectangle.union(nodes[j].Rectangle)
+			waste := []float64{
+				union.area() - nodes[i].Rectangle.area() - nodes[j].Rectangle.area(),
+				union.margin() - nodes[i].Rectangle.margin() - nodes[j].Rectangle.margin(),
+			}
+
+			if worst == nil || slices.Compare(worst, waste) < 0 {
+				seed1, seed2, worst = i, j, waste
+			}
+		}
+	}
+
+	one, another = Nodes{nodes[seed1]}, Nodes{nodes[seed2]}
+	cover1, cover2 := nodes[seed1].Rectangle.clone(), nodes[seed2].Rectangle.clone()
+
+	rest := make(Nodes, 0, len(nodes)-2)
+
+	for i := range nodes {
+		if i != seed1 && i != seed2 {
+			rest = append(rest, nodes[i])
+		}
+	}
+
+	for 0 < len(rest) {
+		// 下限を満たすために残り全てが必要なら割り当てて終了
+		if len(one)+len(rest) <= minSize {
+			return append(one, rest...), another
+		}
+
+		if len(another)+len(rest) <= minSize {
+			return one, append(another, rest...)
+		}
+
+		// PickNext
+		next := 0
+		maxDiff := -1.0
+
+		for i := range rest {
+			d1 := cover1.union(rest[i].Rectangle).area() - cover1.area()
+			d2 := cover2.union(rest[i].Rectangle).area() - cover2.area()
+
+			if maxDiff < math.Abs(d1-d2) {
+				next, maxDiff = i, math.Abs(d1-d2)
+			}
+		}
+
+		entry := rest[next]
+		rest = append(rest[:next], rest[next+1:]...)
+
+		area1, area2 := cover1.area(), cover2.area()
+		score1 := []float64{cover1.union(entry.Rectangle).area() - area1, area1, float64(len(one)), cover1.distance(entry.Rectangle)}
+		score2 := []float64{cover2.union(entry.Rectangle).area() - area2, area2, float64(len(another)), cover2.distance(entry.Rectangle)}
+
+		if slices.Compare(score1, score2) <= 0 {
+			one = append(one, entry)
+			cover1 = cover1.union(entry.Rectangle)
+		} else {
+			another = append(another, entry)
+			cover2 = cover2.union(entry.Rectangle)
+		}
+	}
+
+	return
+}
+
+// R*: 周長の総和が最小の軸を選び、その軸で重なり(同じなら面積)が最小の分け方を選ぶ
+func (nodes Nodes) rstarSplit(minSize int) (one, another Nodes) {
+	// ChooseSplitAxis
+	axis := 0
+	minMargin := math.Inf(1)
+
+	for dim := range nodes[0].Rectangle {
+		var margin float64
+
+		for _, sorted := range nodes.sortedOnAxis(dim) {
+			prefix, suffix := sorted.coverRectangles()
+
+			for k := minSize; k <= len(sorted)-minSize; k++ {
+				margin += prefix[k-1].margin() + suffix[k].margin()
+			}
+		}
+
+		if margin < minMargin {
+			axis, minMargin = dim, margin
+		}
+	}
+
+	// ChooseSplitIndex
+	var best []float64
+
+	for _, sorted := range nodes.sortedOnAxis(axis) {
+		prefix, suffix := sorted.coverRectangles()
+
+		for k := minSize; k <= len(sorted)-minSize; k++ {
+			score := []float64{
+				prefix[k-1].overlapArea(suffix[k]),
+				prefix[k-1].area() + suffix[k].area(),
+			}
+
+			if best == nil || slices.Compare(score, best) < 0 {
+				best = score
+				one = append(Nodes{}, sorted[:k]...)
+				another = append(Nodes{}, sorted[k:]...)
+			}
+		}
+	}
+
+	return
+}
+
+// 軸dimの下端順と上端順に並べたもの
+func (nodes Nodes) sortedOnAxis(dim int) [2]Nodes {
+	byFirst := slices.Clone(nodes)
+	slices.SortStableFunc(byFirst, func(a, b *Node) int {
+		return cmp.Or(cmp.Compare(a.Rectangle[dim].First, b.Rectangle[dim].First), cmp.Compare(a.Rectangle[dim].Second, b.Rectangle[dim].Second))
+	})
+
+	bySecond := slices.Clone(nodes)
+	slices.SortStableFunc(bySecond, func(a, b *Node) int {
+		return cmp.Or(cmp.Compare(a.Rectangle[dim].Second, b.Rectangle[dim].Second), cmp.Compare(a.Rectangle[dim].First, b.Rectangle[dim].First))
+	})
+
+	return [2]Nodes{byFirst, bySecond}
+}
+
+// 先頭からi番目までを包む短形と、i番目から末尾までを包む短形
+func (nodes Nodes) coverRectangles() (prefix, suffix []Rectangle) {
+	prefix = make([]Rectangle, len(nodes))
+	suffix = make([]Rectangle, len(nodes))
+
+	prefix[0] = nodes[0].Rectangle.clone()
+	for i := 1; i < len(nodes); i++ {
+		prefix[i] = prefix[i-1].union(nodes[i].Rectangle)
+	}
+
+	suffix[len(nodes)-1] = nodes[len(nodes)-1].Rectangle.clone()
+	for i := len(nodes) - 2; 0 <= i; i-- {
+		suffix[i] = suffix[i+1].union(nodes[i].Rectangle)
+	}
+
+	return
+}
+
+// R*の強制再挿入: 中心から遠いエントリーを外し、祖先の短形を縮小する. 近いものから再挿入できる順に返す
+func (node *Node) pickReinsertEntries() (entries Nodes) {
+	node.AdjustCoverRectangles()
+
+	count := int(float64(len(node.Children)) * reinsertRatio)
+	if count < 1 {
+		count = 1
+	}
+
+	sorted := slices.Clone(node.Children)
+	slices.SortStableFunc(sorted, func(a, b *Node) int {
+		return cmp.Compare(b.Rectangle.distance(node.Rectangle), a.Rectangle.distance(node.Rectangle))
+	})
+
+	entries = sorted[:count]
+	slices.Reverse(entries)
+
+	for _, entry := range entries {
+		node.Children.delete(entry)
+		entry.Parent = nil
+	}
+
+	for n := node; n != nil; n = n.Parent {
+		n.AdjustCoverRectangles()
+	}
+
+	return
+}
+
+// 面積 (多次元では体積)
+func (rectangle Rectangle) area() (area float64) {
+	area = 1
+
+	for i := range rectangle {
+		area *= rectangle[i].Second - rectangle[i].First
+	}
+
+	return
+}
+
+// 周長 (区間長の総和)
+func (rectangle Rectangle) margin() (margin float64) {
+	for i := range rectangle {
+		margin += rectangle[i].Second - rectangle[i].First
+	}
+
+	return
+}
+
+// 両方の短形を包む短形
+func (rectangle Rectangle) union(other Rectangle) (union Rectangle) {
+	union = make(Rectangle, len(rectangle))
+
+	for i := range rectangle {
+		union[i] = &Inteval{
+			First:  min(rectangle[i].First, other[i].First),
+			Second: max(rectangle[i].Second, other[i].Second),
+		}
+	}
+
+	return
+}
+
+func (rectangle Rectangle) clone() (clone Rectangle) {
+	clone = make(Rectangle, len(rectangle))
+
+	for i := range rectangle {
+		clone[i] = &Inteval{First: rectangle[i].First, Second: rectangle[i].Second}
+	}
+
+	return
+}
@@ -0,0 +1,155 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrategy(t *testing.T) {
+	strategies := map[string]rtree.Strategy{
+		"linear":    rtree.Linear,
+		"quadratic": rtree.Quadratic,
+		"rstar":     rtree.RStar,
+	}
+
+	for name, strategy := range strategies {
+		t.Run(name, func(t *testing.T) {
+			entries := randomEntries(2000, 6)
+			tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 6, Strategy: strategy})
+
+			for _, e := range entries {
+				assert.NoError(t, tree.AddNode(tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First)))
+			}
+
+			assert.NoError(t, tree.Validate())
+
+			depths := leafDepths(tree.Root, 0)
+			for _, d := range depths {
+				assert.Equal(t, depths[0], d)
+			}
+
+			r := rand.New(rand.NewSource(7))
+
+			for i := 0; i < 20; i++ {
+				lat, lon := r.Float64()*150-75, r.Float64()*300-150
+				query := rtree.NewBoundingBox(lat, lon, lat+15, lon+30)
+
+				var want []uint64
+
+				for _, e := range entries {
+					if query[0].First <= e.Rectangle[0].First && e.Rectangle[0].First <= query[0].Second &&
+						query[1].First <= e.Rectangle[1].First && e.Rectangle[1].First <= query[1].Second {
+						want = append(want, e.ID)
+					}
+				}
+
+				ids, err := tree.Search(query)
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, want, ids)
+			}
+
+			for _, e := range entries[:1000] {
+				assert.NoError(t, tree.Delete(e.ID))
+			}
+
+			assert.ElementsMatch(t, collectIDs(tree.Root), func() (ids []uint64) {
+				for _, e := range entries[1000:] {
+					ids = append(ids, e.ID)
+				}
+				return
+			}())
+		})
+	}
+}
+
+func TestQuadraticSplit(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, Strategy: rtree.Quadratic})
+
+	nodes := rtree.Nodes{
+		tree.TakePlace(0, 0, 1),
+		tree.TakePlace(1, 1, 0),
+		tree.TakePlace(2, 1, 2),
+		tree.TakePlace(3, 1, 50),  // 種
+		tree.TakePlace(4, 100, 0), // 種
+	}
+
+	for _, e := range nodes {
+		assert.NoError(t, tree.AddNode(e))
+	}
+
+	// ルートの分割で2つの葉ができる
+	assert.Len(t, tree.Root.Children, 2)
+
+	n, newNode := tree.Root.Children[0], tree.Root.Children[1]
+	if !slices.Contains(n.Children, nodes[0]) {
+		n, newNode = newNode, n
+	}
+
+	assert.ElementsMatch(t, rtree.Nodes{nodes[0], nodes[2], nodes[3]}, n.Children)
+	assert.ElementsMatch(t, rtree.Nodes{nodes[1], nodes[4]}, newNode.Children)
+}
+
+func TestRStarSplit(t *testing.T) {
+	tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4, MinEntrySize: 2, Strategy: rtree.RStar})
+
+	// x軸方向に2つの塊
+	nodes := rtree.Nodes{
+		tree.TakePlace(0, 0, 0),
+		tree.TakePlace(1, 1, 5),
+		tree.TakePlace(2, 50, 1),
+		tree.TakePlace(3, 51, 4),
+		tree.TakePlace(4, 2, 3),
+	}
+
+	for _, e := range nodes {
+		assert.NoError(t, tree.AddNode(e))
+	}
+
+	// ルートの分割で2つの葉ができる
+	assert.Len(t, tree.Root.Children, 2)
+
+	n, newNode := tree.Root.Children[0], tree.Root.Children[1]
+	if !slices.Contains(n.Children, nodes[0]) {
+		n, newNode = newNode, n
+	}
+
+	assert.ElementsMatch(t, rtree.Nodes{nodes[0], nodes[1], nodes[4]}, n.Children)
+	assert.ElementsMatch(t, rtree.Nodes{nodes[2], nodes[3]}, newNode.Children)
+}
+
+func BenchmarkStrategy(b *testing.B) {
+	entries := randomEntries(20000, 8)
+	strategies := map[string]rtree.Strategy{
+		"linear":    rtree.Linear,
+		"quadratic": rtree.Quadratic,
+		"rstar":     rtree.RStar,
+	}
+
+	for name, strategy := range strategies {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 16, Strategy: strategy})
+
+		b.Run("insert "+name, func(bSub *testing.B) {
+			for i := 0; i < bSub.N; i++ {
+				tree = rtree.NewRTree(&rtree.Config{MaxEntrySize: 16, Strategy: strategy})
+
+				for _, e := range entries {
+					_ = tree.AddNode(tree.TakePlace(e.ID, e.Rectangle[0].First, e.Rectangle[1].First))
+				}
+			}
+		})
+
+		b.Run("search "+name, func(bSub *testing.B) {
+			r := rand.New(rand.NewSource(9))
+
+			bSub.ResetTimer()
+			for i := 0; i < bSub.N; i++ {
+				lat, lon := r.Float64()*170-85, r.Float64()*350-175
+				_, _ = tree.Search(rtree.NewBoundingBox(lat, lon, lat+5, lon+5))
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package rtree
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+type (
+	// TemporalTree 有効期間を持つエントリーの時空間索引
+	// 緯度, 経度, 時刻の3次元の木に格納し、期間も木の探索で枝刈りする
+	// 期限の無い側は面積が発散しないよう、索引の対象期間 (WithHorizon) の1単位外側の時刻として格納する
+	TemporalTree struct {
+		tree    *RTree
+		scale   float64 // 時刻の次元の1単位の秒数
+		horizon TimeWindow
+	}
+
+	// TimeWindow 有効期間 [Start, End). ゼロ値の時刻は期限が無いことを表す
+	// 時刻は浮動小数点数で格納するため、マイクロ秒未満の差は区別されない
+	TimeWindow struct {
+		Start time.Time
+		End   time.Time
+	}
+
+	TemporalOption func(*TemporalTree)
+)
+
+const (
+	temporalDimension = 3
+
+	// 緯度経度の1度に対応させる既定の期間
+	defaultTimeScale = time.Hour
+)
+
+//nolint:gochecknoglobals
+var (
+	ErrInvalidTimeWindow = errors.New("rtree: invalid time window")
+
+	// 既定の索引の対象期間
+	defaultHorizon = TimeWindow{
+		Start: time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2200, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+)
+
+// WithTimeScale 時刻の次元の1単位をdとする. 緯度経度の1度と同程度に扱う期間を指定する. 既定は1時間
+// 挿入先の選択と分割は各次元の広がりで判断するため、時刻の次元だけが広すぎたり狭すぎたりしないようにする
+func WithTimeScale(d time.Duration) TemporalOption {
+	return func(t *TemporalTree) {
+		if 0 < d {
+			t.scale = d.Seconds()
+		}
+	}
+}
+
+// WithHorizon 索引の対象期間を [start, end] とする. 既定は1900年から2200年
+// 期限の有るエントリーの期間は対象期間内に限る. 期限の無い側は対象期間の外側まで有効なものとして扱う
+func WithHorizon(start, end time.Time) TemporalOption {
+	return func(t *TemporalTree) {
+		if start.Before(end) {
+			t.horizon = TimeWindow{Start: start, End: end}
+		}
+	}
+}
+
+// NewTemporalTree 時空間索引を作成する. cnf.Dimension は3として扱う
+func NewTemporalTree(cnf *Config, opts ...TemporalOption) (t *TemporalTree) {
+	c := *cnf
+	c.Dimension = temporalDimension
+
+	t = &TemporalTree{
+		tree:    NewRTree(&c),
+		scale:   defaultTimeScale.Seconds(),
+		horizon: defaultHorizon,
+	}
+
+	for _, opt := range opts {
+		opt(t)
+	}
+
+	return
+}
+
+// RTree 3次元目が時刻の元の木
+func (t *TemporalTree) RTree() *RTree {
+	return t.tree
+}
+
+// Insert 緯度経度の短形と有効期間のエントリーを挿入する
+// 期限の有る側の時刻が対象期間 (WithHorizon) の外なら ErrInvalidTimeWindow を返す
+func (t *TemporalTree) Insert(id uint64, rectangle Rectangle, window TimeWindow) error {
+	interval, err := t.entryInterval(rectangle, window)
+	if err != nil {
+		return err
+	}
+
+	return t.tree.AddNode(t.tree.TakeRectangle(id, append(rectangle.clone(), interval)))
+}
+
+// InsertPlace 地点と有効期間のエントリーを挿入する
+func (t *TemporalTree) InsertPlace(id uint64, lat, lon float64, window TimeWindow) error {
+	return t.Insert(id, NewBoundingBox(lat, lon, lat, lon), window)
+}
+
+func (t *TemporalTree) Delete(id uint64) error {
+	return t.tree.Delete(id)
+}
+
+// Update 短形と有効期間を更新する. 短形または期間が不正ならエントリーは元のまま
+func (t *TemporalTree) Update(id uint64, rectangle Rectangle, window TimeWindow) error {
+	interval, err := t.entryInterval(rectangle, window)
+	if err != nil {
+		return err
+	}
+
+	return t.tree.Update(id, append(rectangle.clone(), interval))
+}
+
+// SearchAt 短形に該当し、時刻atに有効なエントリーのIDを返却する
+func (t *TemporalTree) SearchAt(rectangle Rectangle, at time.Time, opts ...SearchOption) ([]uint64, error) {
+	if !t.spatial(rectangle) {
+		return nil, ErrInvalidRectangle
+	}
+
+	s := t.coordinate(at)
+
+	return t.search(rectangle, &Inteval{First: s, Second: s}, opts)
+}
+
+// SearchDuring 短形に該当し、有効期間がwindowと重なるエントリーのIDを返却する
+// WithMode の判定方法は時刻の次元にも適用される
+func (t *TemporalTree) SearchDuring(rectangle Rectangle, window TimeWindow, opts ...SearchOption) ([]uint64, error) {
+	interval, err := t.interval(rectangle, window)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.search(rectangle, interval, opts)
+}
+
+// 探索する時刻の区間を格納した時刻に合わせて探索する
+// 期限の無い側は期限の無いエントリーと同じ時刻、対象期間の外の時刻は対象期間と期限の無い側の時刻の間に寄せる
+func (t *TemporalTree) search(rectangle Rectangle, interval *Inteval, opts []SearchOption) ([]uint64, error) {
+	start, end := t.coordinate(t.horizon.Start), t.coordinate(t.horizon.End)
+
+	clamp := func(v float64) float64 {
+		switch {
+		case math.IsInf(v, -1):
+			return start - 1
+		case math.IsInf(v, 1):
+			return end + 1
+		}
+
+		return math.Max(start-0.5, math.Min(v, end+0.5))
+	}
+
+	return t.tree.Search(append(rectangle.clone(), &Inteval{First: clamp(interval.First), Second: clamp(interval.Second)}), opts...)
+}
+
+// 緯度経度の短形か判定
+func (t *TemporalTree) spatial(rectangle Rectangle) bool {
+	return (&Config{Dimension: defaultDimension}).validRectangle(rectangle)
+}
+
+// 格納する時刻の次元の区間. 期限の無い側は対象期間の1単位外側とする
+func (t *TemporalTree) entryInterval(rectangle Rectangle, window TimeWindow) (*Inteval, error) {
+	interval, err := t.interval(rectangle, window)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := t.coordinate(t.horizon.Start), t.coordinate(t.horizon.End)
+
+	if window.Start.IsZero() {
+		interval.First = start - 1
+	} else if interval.First < start {
+		return nil, ErrInvalidTimeWindow
+	}
+
+	if window.End.IsZero() {
+		interval.Second = end + 1
+	} else if end < interval.Second {
+		return nil, ErrInvalidTimeWindow
+	}
+
+	return interval, nil
+}
+
+// 期間を時刻の次元の区間にする. 期限が無い側は無限大とする
+// 半開区間 [Start, End) を閉区間 [Start, Endの直前の値] として格納し、閉区間同士の重なりで判定する
+func (t *TemporalTree) interval(rectangle Rectangle, window TimeWindow) (*Inteval, error) {
+	if !t.spatial(rectangle) {
+		return nil, ErrInvalidRectangle
+	}
+
+	start, end := math.Inf(-1), math.Inf(1)
+
+	if !window.Start.IsZero() {
+		start = t.coordinate(window.Start)
+	}
+
+	if !window.End.IsZero() {
+		end = t.coordinate(window.End)
+	}
+
+	if end <= start {
+		return nil, ErrInvalidTimeWindow
+	}
+
+	if !math.IsInf(end, 1) {
+		end = math.Nextafter(end, math.Inf(-1))
+	}
+
+	return &Inteval{First: start, Second: end}, nil
+}
+
+// 時刻の次元の座標. Unix秒を時刻の尺度で割ったもの
+func (t *TemporalTree) coordinate(at time.Time) float64 {
+	return (float64(at.Unix()) + float64(at.Nanosecond())/1e9) / t.scale
+}
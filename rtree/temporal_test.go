@@ -0,0 +1,197 @@
+package rtree_test
+
+import (
+	"math/rand"
+	"rtree"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemporalTree(t *testing.T) {
+	tree := rtree.NewTemporalTree(&rtree.Config{MaxEntrySize: 3})
+	base := time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local)
+	hour := func(h int) time.Time { return base.Add(time.Duration(h) * time.Hour) }
+
+	// 東京駅周辺のポップアップストア
+	assert.NoError(t, tree.InsertPlace(1, 35.681, 139.767, rtree.TimeWindow{Start: hour(0), End: hour(8)}))
+	assert.NoError(t, tree.InsertPlace(2, 35.682, 139.768, rtree.TimeWindow{Start: hour(8), End: hour(12)}))
+	assert.NoError(t, tree.InsertPlace(3, 35.683, 139.766, rtree.TimeWindow{Start: hour(4)}))
+	assert.NoError(t, tree.InsertPlace(4, 35.690, 139.700, rtree.TimeWindow{End: hour(24)}))
+	assert.NoError(t, tree.Insert(5, rtree.NewBoundingBox(35.6, 139.6, 35.7, 139.8), rtree.TimeWindow{Start: hour(100), End: hour(101)}))
+
+	tokyo := rtree.NewBoundingBox(35.68, 139.76, 35.69, 139.77)
+
+	t.Run("at", func(t *testing.T) {
+		for _, c := range []struct {
+			at       time.Time
+			expected []uint64
+		}{
+			{hour(-1), nil},
+			{hour(0), []uint64{1}},
+			{hour(5), []uint64{1, 3}},
+			{hour(8), []uint64{2, 3}},
+			{hour(8).Add(-time.Millisecond), []uint64{1, 3}},
+			{hour(12), []uint64{3}},
+			{hour(100), []uint64{3, 5}},
+		} {
+			ids, err := tree.SearchAt(tokyo, c.at)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, c.expected, ids, c.at)
+		}
+	})
+
+	t.Run("during", func(t *testing.T) {
+		ids, err := tree.SearchDuring(tokyo, rtree.TimeWindow{Start: hour(7), End: hour(9)})
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, ids)
+
+		ids, _ = tree.SearchDuring(tokyo, rtree.TimeWindow{Start: hour(12), End: hour(13)})
+		assert.ElementsMatch(t, []uint64{3}, ids, "終了時刻は含まない")
+
+		ids, _ = tree.SearchDuring(rtree.NewBoundingBox(35, 139, 36, 140), rtree.TimeWindow{End: hour(0)})
+		assert.ElementsMatch(t, []uint64{4}, ids)
+
+		ids, _ = tree.SearchDuring(rtree.NewBoundingBox(35, 139, 36, 140), rtree.TimeWindow{})
+		assert.ElementsMatch(t, []uint64{1, 2, 3, 4, 5}, ids)
+
+		ids, _ = tree.SearchDuring(rtree.NewBoundingBox(35, 139, 36, 140), rtree.TimeWindow{Start: hour(0), End: hour(24)}, rtree.WithMode(rtree.Within))
+		assert.ElementsMatch(t, []uint64{1, 2}, ids)
+	})
+
+	t.Run("update delete", func(t *testing.T) {
+		assert.NoError(t, tree.Update(5, tokyo, rtree.TimeWindow{Start: hour(0), End: hour(1)}))
+
+		ids, _ := tree.SearchAt(tokyo, hour(0))
+		assert.ElementsMatch(t, []uint64{1, 5}, ids)
+
+		assert.NoError(t, tree.Delete(5))
+		ids, _ = tree.SearchAt(tokyo, hour(0))
+		assert.ElementsMatch(t, []uint64{1}, ids)
+		assert.NoError(t, tree.RTree().Validate())
+	})
+
+	t.Run("unbounded", func(t *testing.T) {
+		ids, err := tree.SearchAt(tokyo, hour(1_000_000))
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []uint64{3}, ids)
+
+		ids, _ = tree.SearchDuring(rtree.NewBoundingBox(35, 139, 36, 140), rtree.TimeWindow{}, rtree.WithLimit(3))
+		assert.Len(t, ids, 3)
+
+		ids, _ = tree.SearchDuring(rtree.NewBoundingBox(35, 139, 36, 140), rtree.TimeWindow{Start: hour(-100)}, rtree.WithMode(rtree.Within))
+		assert.ElementsMatch(t, []uint64{1, 2, 3}, ids)
+
+		assert.ErrorIs(t, tree.InsertPlace(3, 0, 0, rtree.TimeWindow{Start: hour(0), End: hour(1)}), rtree.ErrDuplicateID)
+		assert.ErrorIs(t, tree.InsertPlace(1, 0, 0, rtree.TimeWindow{Start: hour(0)}), rtree.ErrDuplicateID)
+	})
+
+	t.Run("horizon", func(t *testing.T) {
+		tree := rtree.NewTemporalTree(&rtree.Config{MaxEntrySize: 3}, rtree.WithHorizon(hour(0), hour(100)))
+
+		assert.NoError(t, tree.InsertPlace(1, 35.681, 139.767, rtree.TimeWindow{Start: hour(10)}))
+		assert.NoError(t, tree.InsertPlace(2, 35.681, 139.767, rtree.TimeWindow{End: hour(90)}))
+		assert.NoError(t, tree.InsertPlace(3, 35.681, 139.767, rtree.TimeWindow{Start: hour(0), End: hour(100)}))
+		assert.ErrorIs(t, tree.InsertPlace(4, 35.681, 139.767, rtree.TimeWindow{Start: hour(-1), End: hour(1)}), rtree.ErrInvalidTimeWindow)
+		assert.ErrorIs(t, tree.InsertPlace(4, 35.681, 139.767, rtree.TimeWindow{Start: hour(99), End: hour(101)}), rtree.ErrInvalidTimeWindow)
+
+		// 期限の無いエントリーも時刻の次元で枝刈りされ、木の時刻の範囲は対象期間の1単位外側まで
+		bounds := tree.RTree().Root.Rectangle[2]
+		assert.InDelta(t, 102, bounds.Second-bounds.First, 1e-6)
+
+		for _, c := range []struct {
+			at       time.Time
+			expected []uint64
+		}{
+			{hour(-1000), []uint64{2}},
+			{hour(50), []uint64{1, 2, 3}},
+			{hour(100), []uint64{1}},
+			{hour(1000), []uint64{1}},
+		} {
+			ids, err := tree.SearchAt(tokyo, c.at)
+			assert.NoError(t, err)
+			assert.ElementsMatch(t, c.expected, ids, c.at)
+		}
+
+		ids, _ := tree.SearchDuring(tokyo, rtree.TimeWindow{Start: hour(-10), End: hour(200)}, rtree.WithMode(rtree.Within))
+		assert.ElementsMatch(t, []uint64{3}, ids)
+
+		ids, _ = tree.SearchDuring(tokyo, rtree.TimeWindow{Start: hour(5)}, rtree.WithMode(rtree.Within))
+		assert.ElementsMatch(t, []uint64{1}, ids)
+
+		place := rtree.NewBoundingBox(35.681, 139.767, 35.681, 139.767)
+		ids, _ = tree.SearchDuring(place, rtree.TimeWindow{Start: hour(20), End: hour(1000)}, rtree.WithMode(rtree.Contains))
+		assert.ElementsMatch(t, []uint64{1}, ids)
+	})
+
+	t.Run("time scale", func(t *testing.T) {
+		tree := rtree.NewTemporalTree(&rtree.Config{MaxEntrySize: 3}, rtree.WithTimeScale(24*time.Hour))
+
+		assert.NoError(t, tree.InsertPlace(1, 35.681, 139.767, rtree.TimeWindow{Start: hour(0), End: hour(48)}))
+
+		bounds := tree.RTree().Root.Rectangle[2]
+		assert.InDelta(t, 2, bounds.Second-bounds.First, 1e-6, "1単位が1日")
+
+		ids, _ := tree.SearchAt(tokyo, hour(47))
+		assert.Equal(t, []uint64{1}, ids)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		assert.ErrorIs(t, tree.InsertPlace(9, 0, 0, rtree.TimeWindow{Start: hour(1), End: hour(1)}), rtree.ErrInvalidTimeWindow)
+		assert.ErrorIs(t, tree.InsertPlace(9, 0, 0, rtree.TimeWindow{Start: hour(2), End: hour(1)}), rtree.ErrInvalidTimeWindow)
+
+		_, err := tree.SearchAt(rect(0, 1, 0, 1)[:1], hour(0))
+		assert.ErrorIs(t, err, rtree.ErrInvalidRectangle)
+	})
+
+	t.Run("brute force", func(t *testing.T) {
+		r := rand.New(rand.NewSource(61))
+		tree := rtree.NewTemporalTree(&rtree.Config{MaxEntrySize: 6, Strategy: rtree.RStar})
+
+		type event struct {
+			lat, lon   float64
+			start, end time.Time
+		}
+
+		events := make(map[uint64]event)
+
+		for i := 0; i < 1000; i++ {
+			start := hour(r.Intn(1000))
+			e := event{lat: r.Float64() * 10, lon: r.Float64() * 10, start: start, end: start.Add(time.Duration(1+r.Intn(48)) * time.Hour)}
+			events[uint64(i)] = e
+			assert.NoError(t, tree.InsertPlace(uint64(i), e.lat, e.lon, rtree.TimeWindow{Start: e.start, End: e.end}))
+		}
+
+		assert.NoError(t, tree.RTree().Validate())
+
+		for i := 0; i < 100; i++ {
+			lat, lon := r.Float64()*8, r.Float64()*8
+			box := rtree.NewBoundingBox(lat, lon, lat+2, lon+2)
+			at := hour(r.Intn(1000))
+			end := at.Add(time.Duration(1+r.Intn(10)) * time.Hour)
+
+			var expectedAt, expectedDuring []uint64
+
+			for id, e := range events {
+				if e.lat < lat || lat+2 < e.lat || e.lon < lon || lon+2 < e.lon {
+					continue
+				}
+
+				if !at.Before(e.start) && at.Before(e.end) {
+					expectedAt = append(expectedAt, id)
+				}
+
+				if e.start.Before(end) && at.Before(e.end) {
+					expectedDuring = append(expectedDuring, id)
+				}
+			}
+
+			ids, _ := tree.SearchAt(box, at)
+			assert.ElementsMatch(t, expectedAt, ids)
+
+			ids, _ = tree.SearchDuring(box, rtree.TimeWindow{Start: at, End: end})
+			assert.ElementsMatch(t, expectedDuring, ids)
+		}
+	})
+}
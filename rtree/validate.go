@@ -0,0 +1,127 @@
+package rtree
+
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrInvalidTree = errors.New("rtree: invalid tree")
+
+// Validate 木の構造の不変条件を検査する. 違反があれば最初に見つけたものを ErrInvalidTree として返す
+//   - 親子のポインタと所属する木が一致する
+//   - ノードの短形が子の短形を包含する
+//   - ルート以外のノードの子の数が MinEntrySize 以上 MaxEntrySize 以下
+//   - リーフエントリーは全て同じ深さにあり、葉ノードの子は全てリーフエントリー
+//   - 同じIDのリーフエントリーが無く、索引と一致する
+func (tree *RTree) Validate() (err error) {
+	root := tree.Root
+	if root == nil {
+		return fmt.Errorf("%w: no root", ErrInvalidTree)
+	}
+
+	if root.Parent != nil {
+		return fmt.Errorf("%w: root has parent", ErrInvalidTree)
+	}
+
+	if root.DataID != nil {
+		return fmt.Errorf("%w: root is entry", ErrInvalidTree)
+	}
+
+	if !tree.validRectangle(root.Rectangle) {
+		return fmt.Errorf("%w: root has %d dimensions", ErrInvalidTree, len(root.Rectangle))
+	}
+
+	if tree.cnf.MaxEntrySize < len(root.Children) {
+		return fmt.Errorf("%w: root has %d children", ErrInvalidTree, len(root.Children))
+	}
+
+	if !root.isLeaf() && len(root.Children) < 2 {
+		return fmt.Errorf("%w: internal root has %d child", ErrInvalidTree, len(root.Children))
+	}
+
+	leafDepth := -1
+	seen := make(map[uint64]bool)
+
+	tree.Walk(func(node *Node, depth int) bool {
+		if err != nil {
+			return false
+		}
+
+		err = tree.validateNode(node, depth, &leafDepth, seen)
+
+		return err == nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if len(seen) != len(tree.entries) {
+		return fmt.Errorf("%w: %d entries in tree, %d indexed", ErrInvalidTree, len(seen), len(tree.entries))
+	}
+
+	return nil
+}
+
+// ノード単体と子との関係を検査する
+func (tree *RTree) validateNode(node *Node, depth int, leafDepth *int, seen map[uint64]bool) error {
+	if node.Tree != tree {
+		return fmt.Errorf("%w: node at depth %d belongs to another tree", ErrInvalidTree, depth)
+	}
+
+	if node.DataID != nil {
+		id := *node.DataID
+
+		if len(node.Children) != 0 {
+			return fmt.Errorf("%w: entry %d has children", ErrInvalidTree, id)
+		}
+
+		if seen[id] {
+			return fmt.Errorf("%w: duplicate entry %d", ErrInvalidTree, id)
+		}
+
+		seen[id] = true
+
+		if tree.entries[id] != node {
+			return fmt.Errorf("%w: entry %d is not indexed", ErrInvalidTree, id)
+		}
+
+		if *leafDepth == -1 {
+			*leafDepth = depth
+		}
+
+		if depth != *leafDepth {
+			return fmt.Errorf("%w: entry %d at depth %d, expected %d", ErrInvalidTree, id, depth, *leafDepth)
+		}
+
+		return nil
+	}
+
+	if node != tree.Root {
+		if len(node.Children) < tree.cnf.minEntrySize() || tree.cnf.MaxEntrySize < len(node.Children) {
+			return fmt.Errorf("%w: node at depth %d has %d children", ErrInvalidTree, depth, len(node.Children))
+		}
+	}
+
+	leaf := node.hasDataNode()
+
+	for _, child := range node.Children {
+		if child.Parent != node {
+			return fmt.Errorf("%w: parent pointer mismatch at depth %d", ErrInvalidTree, depth+1)
+		}
+
+		if !tree.validRectangle(child.Rectangle) {
+			return fmt.Errorf("%w: node at depth %d has %d dimensions", ErrInvalidTree, depth+1, len(child.Rectangle))
+		}
+
+		if (child.DataID != nil) != leaf {
+			return fmt.Errorf("%w: node at depth %d mixes entries and nodes", ErrInvalidTree, depth)
+		}
+
+		if !node.Rectangle.cover(child.Rectangle) {
+			return fmt.Errorf("%w: node at depth %d does not cover child %s", ErrInvalidTree, depth, child.Rectangle.label())
+		}
+	}
+
+	return nil
+}
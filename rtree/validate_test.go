@@ -0,0 +1,212 @@
+package rtree_test
+
+import (
+	"bytes"
+	"math/rand"
+	"rtree"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	build := func() *rtree.RTree {
+		tree := rtree.NewRTree(&rtree.Config{MaxEntrySize: 4})
+
+		for _, e := range randomEntries(200, 41) {
+			_ = tree.AddNode(tree.TakeRectangle(e.ID, e.Rectangle))
+		}
+
+		return tree
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		assert.NoError(t, rtree.NewRTree(&rtree.Config{MaxEntrySize: 4}).Validate())
+		assert.NoError(t, build().Validate())
+
+		for _, packing := range []rtree.Packing{rtree.STR, rtree.Hilbert} {
+			for _, dimension := range []int{2, 3} {
+				for _, size := range []int{2, 3, 4, 6} {
+					for n := 1; n <= 300; n++ {
+						cnf := &rtree.Config{MaxEntrySize: size, Packing: packing, Dimension: dimension}
+
+						tree, err := rtree.BulkLoad(cnf, randomEntriesIn(dimension, n, 42))
+						assert.NoError(t, err)
+						assert.NoError(t, tree.Validate(), "packing %d, %dD, M=%d, %d entries", packing, dimension, size, n)
+					}
+				}
+			}
+		}
+
+		var buf bytes.Buffer
+		assert.NoError(t, build().Save(&buf))
+
+		loaded, err := rtree.Load(&buf)
+		assert.NoError(t, err)
+		assert.NoError(t, loaded.Validate())
+	})
+
+	leaf := func(tree *rtree.RTree) *rtree.Node {
+		node := tree.Root
+		for node.Children[0].DataID == nil {
+			node = node.Children[0]
+		}
+
+		return node
+	}
+
+	for name, corrupt := range map[string]func(tree *rtree.RTree){
+		"parent pointer": func(tree *rtree.RTree) {
+			tree.Root.Children[0].Parent = tree.Root.Children[1]
+		},
+		"not covered": func(tree *rtree.RTree) {
+			leaf(tree).Rectangle = rect(1000, 1001, 1000, 1001)
+		},
+		"overflow": func(tree *rtree.RTree) {
+			node := leaf(tree)
+			for i := 0; i < 4; i++ {
+				node.Children = append(node.Children, node.Children[0])
+			}
+		},
+		"underflow": func(tree *rtree.RTree) {
+			node := leaf(tree)
+			node.Children = node.Children[:0]
+		},
+		"duplicate": func(tree *rtree.RTree) {
+			node := leaf(tree)
+			node.Children = append(node.Children[:len(node.Children)-1], node.Children[0])
+		},
+		"leaf depth": func(tree *rtree.RTree) {
+			entry := leaf(tree).Children[0]
+			child := tree.Root.Children[0]
+			child.Children = append(child.Children, entry)
+		},
+		"root with one child": func(tree *rtree.RTree) {
+			tree.Root.Children = tree.Root.Children[:1]
+		},
+		"dimension": func(tree *rtree.RTree) {
+			leaf(tree).Children[0].Rectangle = rtree.Rectangle{&rtree.Inteval{}}
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tree := build()
+			corrupt(tree)
+
+			assert.ErrorIs(t, tree.Validate(), rtree.ErrInvalidTree)
+		})
+	}
+}
+
+// 4バイト毎の操作列を木と素朴な実装の両方に適用し、探索結果と不変条件を検査する
+func runOperations(t *testing.T, cnf *rtree.Config, ops []byte) {
+	t.Helper()
+
+	tree := rtree.NewRTree(cnf)
+	oracle := make(map[uint64]rtree.Rectangle)
+
+	area := func(a, b, size byte) rtree.Rectangle {
+		return rect(float64(a), float64(a)+float64(size%16), float64(b), float64(b)+float64(size%16))
+	}
+
+	for i := 0; i+4 <= len(ops); i += 4 {
+		id := uint64(ops[i+1] % 64)
+
+		switch ops[i] % 4 {
+		case 0:
+			r := area(ops[i+2], ops[i+3], ops[i+1]/64*5)
+			err := tree.AddNode(tree.TakeRectangle(id, r))
+
+			if _, ok := oracle[id]; ok {
+				assert.ErrorIs(t, err, rtree.ErrDuplicateID)
+			} else {
+				assert.NoError(t, err)
+				oracle[id] = r
+			}
+		case 1:
+			err := tree.Delete(id)
+
+			if _, ok := oracle[id]; ok {
+				assert.NoError(t, err)
+				delete(oracle, id)
+			} else {
+				assert.ErrorIs(t, err, rtree.ErrNotFound)
+			}
+		case 2:
+			r := area(ops[i+2], ops[i+3], 0)
+			err := tree.Update(id, r)
+
+			if _, ok := oracle[id]; ok {
+				assert.NoError(t, err)
+				oracle[id] = r
+			} else {
+				assert.ErrorIs(t, err, rtree.ErrNotFound)
+			}
+		case 3:
+			query := area(ops[i+1], ops[i+2], ops[i+3])
+
+			for _, mode := range []rtree.SearchMode{rtree.Intersects, rtree.Within, rtree.Contains} {
+				var expected []uint64
+
+				for id, r := range oracle {
+					if (mode == rtree.Intersects && overlaps(r, query)) ||
+						(mode == rtree.Within && covers(query, r)) ||
+						(mode == rtree.Contains && covers(r, query)) {
+						expected = append(expected, id)
+					}
+				}
+
+				ids, err := tree.Search(query, rtree.WithMode(mode))
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, expected, ids, "mode %d", mode)
+			}
+		}
+
+		if err := tree.Validate(); err != nil {
+			t.Fatalf("operation %d: %v", i/4, err)
+		}
+	}
+
+	assert.Equal(t, len(oracle), tree.Stats().Entries)
+}
+
+func overlaps(a, b rtree.Rectangle) bool {
+	for i := range a {
+		if a[i].Second < b[i].First || b[i].Second < a[i].First {
+			return false
+		}
+	}
+
+	return true
+}
+
+func covers(a, b rtree.Rectangle) bool {
+	for i := range a {
+		if b[i].First < a[i].First || a[i].Second < b[i].Second {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestRandomOperations(t *testing.T) {
+	for _, strategy := range []rtree.Strategy{rtree.Linear, rtree.Quadratic, rtree.RStar} {
+		for size := 2; size <= 8; size++ {
+			r := rand.New(rand.NewSource(int64(size)*10 + int64(strategy)))
+
+			ops := make([]byte, 4*500)
+			r.Read(ops)
+
+			runOperations(t, &rtree.Config{MaxEntrySize: size, Strategy: strategy}, ops)
+		}
+	}
+}
+
+func FuzzOperations(f *testing.F) {
+	f.Add(uint8(4), uint8(0), []byte{0, 1, 2, 3, 0, 2, 2, 3, 0, 3, 200, 7, 3, 0, 0, 255, 1, 1, 0, 0, 2, 2, 9, 9})
+	f.Add(uint8(2), uint8(2), bytes.Repeat([]byte{0, 7, 3, 3, 0, 71, 3, 3, 1, 7, 0, 0, 3, 0, 0, 40}, 8))
+
+	f.Fuzz(func(t *testing.T, size, strategy uint8, ops []byte) {
+		runOperations(t, &rtree.Config{MaxEntrySize: 2 + int(size%8), Strategy: rtree.Strategy(strategy % 3)}, ops)
+	})
+}